@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/kataras/jwt"
 	"github.com/micahasowata/blog/internal/models"
@@ -14,6 +13,7 @@ import (
 
 func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
 	var input struct {
+		Email string `json:"email" validate:"required,email"`
 		Token string `json:"token" validate:"required,len=6"`
 	}
 
@@ -29,25 +29,19 @@ func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	email, err := app.rclient.Get(r.Context(), input.Token).Result()
-	if err != nil || email == "" {
-		app.invalidTokenHandler(w, err)
+	err = app.consumeOTP(r.Context(), otpVerifyEmail, input.Email, app.clientIP(r), input.Token)
+	if err != nil {
+		app.otpErrHandler(w, err)
 		return
 	}
 
-	user, err := app.models.Users.GetByEmail(email)
+	user, err := app.models.Users.GetByEmail(input.Email)
 	if err != nil {
 		app.invalidTokenHandler(w, err)
 		return
 	}
 
 	if user.Verified {
-		err := app.rclient.Del(r.Context(), input.Token).Err()
-		if err != nil {
-			app.serverErrorHandler(w, err)
-			return
-		}
-
 		err = app.Write(w, http.StatusOK, jason.Envelope{"user": user}, nil)
 		if err != nil {
 			app.writeErrHandler(w, err)
@@ -57,18 +51,12 @@ func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err = app.models.Users.VerifyEmail(email)
+	user, err = app.models.Users.VerifyEmail(user.Email)
 	if err != nil {
 		app.invalidTokenHandler(w, err)
 		return
 	}
 
-	err = app.rclient.Del(r.Context(), input.Token).Err()
-	if err != nil {
-		app.serverErrorHandler(w, err)
-		return
-	}
-
 	err = app.Write(w, http.StatusOK, jason.Envelope{"user": user}, nil)
 	if err != nil {
 		app.writeErrHandler(w, err)
@@ -104,9 +92,7 @@ func (app *application) createLoginToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token := app.newToken()
-
-	err = app.rclient.Set(r.Context(), token, user.Email, 5*time.Hour).Err()
+	token, err := app.issueOTP(r.Context(), otpLogin, user.Email)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
@@ -141,6 +127,7 @@ func (app *application) createLoginToken(w http.ResponseWriter, r *http.Request)
 
 func (app *application) loginUser(w http.ResponseWriter, r *http.Request) {
 	var input struct {
+		Email string `json:"email" validate:"required,email"`
 		Token string `json:"token" validate:"required,len=6"`
 	}
 
@@ -156,13 +143,13 @@ func (app *application) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	email, err := app.rclient.Get(r.Context(), input.Token).Result()
-	if err != nil || email == "" {
-		app.invalidTokenHandler(w, err)
+	err = app.consumeOTP(r.Context(), otpLogin, input.Email, app.clientIP(r), input.Token)
+	if err != nil {
+		app.otpErrHandler(w, err)
 		return
 	}
 
-	user, err := app.models.Users.GetByEmail(email)
+	user, err := app.models.Users.GetByEmail(input.Email)
 	if err != nil {
 		app.invalidTokenHandler(w, err)
 		return
@@ -176,42 +163,39 @@ func (app *application) loginUser(w http.ResponseWriter, r *http.Request) {
 
 	device := app.getUserDeviceInfo(app.getUserAgent(r))
 
-	payload := loginEmailPayload{
-		To:       user.Email,
-		Name:     user.Name,
-		Location: location,
-		Device:   device,
-	}
-
-	task, err := app.newLoginEmailTask(payload)
+	suppressed, err := app.suppressed(user.Email, categoryLoginAlerts)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
 	}
 
-	_, err = app.executor.EnqueueContext(r.Context(), task)
-	if err != nil {
-		app.serverErrorHandler(w, err)
-		return
-	}
+	if !suppressed {
+		payload := loginEmailPayload{
+			To:       user.Email,
+			Name:     user.Name,
+			Location: location,
+			Device:   device,
+		}
 
-	accessToken, err := app.newAccessToken(&tokenClaims{ID: user.ID})
-	if err != nil {
-		app.serverErrorHandler(w, err)
-		return
+		task, err := app.newLoginEmailTask(payload)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+
+		_, err = app.executor.EnqueueContext(r.Context(), task)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
 	}
 
-	refreshToken, err := app.newRefreshToken(&tokenClaims{ID: user.ID})
+	pair, err := app.startSession(r.Context(), user.ID)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
 	}
 
-	pair := map[string]string{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-	}
-
 	err = app.Write(w, http.StatusOK, jason.Envelope{"user": user, "token_pair": pair}, nil)
 	if err != nil {
 		app.writeErrHandler(w, err)
@@ -228,55 +212,75 @@ func (app *application) logoutUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.blocklist.InvalidateToken(verifiedToken.Token, verifiedToken.StandardClaims)
+	claims := &tokenClaims{}
+	err = verifiedToken.Claims(&claims)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
 	}
 
-	err = app.Write(w, http.StatusOK, jason.Envelope{"user": "logged out successfully"}, nil)
-	if err != nil {
-		app.writeErrHandler(w, err)
-		return
+	// The jti sits in StdClaims, so that is what the blocklist is keyed on.
+	stdClaims := verifiedToken.StandardClaims
+	if claims.StdClaims != nil {
+		stdClaims = *claims.StdClaims
 	}
-}
-
-func (app *application) refreshToken(w http.ResponseWriter, r *http.Request) {
-	token := r.Context().Value(userToken).(string)
 
-	verifiedToken, err := jwt.Verify(jwt.HS256, app.config.Key, []byte(token), app.blocklist)
+	err = app.blocklist.InvalidateToken(r.Context(), verifiedToken.Token, stdClaims)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
 	}
 
-	claims := &tokenClaims{}
-	err = verifiedToken.Claims(&claims)
+	// Logging out also ends the refresh tokens issued with this token.
+	if claims.StdClaims != nil && claims.StdClaims.OriginID != "" {
+		err = app.blocklist.RevokeFamily(r.Context(), claims.StdClaims.OriginID, refreshTokenTTL)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"user": "logged out successfully"}, nil)
 	if err != nil {
-		app.serverErrorHandler(w, err)
+		app.writeErrHandler(w, err)
 		return
 	}
+}
 
-	accessToken, err := app.newAccessToken(&tokenClaims{ID: claims.ID})
+// refreshToken rotates a refresh token within its family. A refresh token
+// is only good once, so seeing one again means it was stolen: the family is
+// revoked and the user is told by email.
+func (app *application) refreshToken(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(userClaims).(*tokenClaims)
+
+	origin := claims.StdClaims.OriginID
+
+	pair, refreshClaims, err := app.newTokenPair(claims.ID, origin)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
 	}
 
-	refreshToken, err := app.newRefreshToken(&tokenClaims{ID: claims.ID})
+	rotated, err := app.blocklist.RotateFamily(r.Context(), origin, claims.StdClaims.ID, refreshClaims.ID, refreshTokenTTL)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
 	}
 
-	pair := map[string]string{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-	}
+	switch rotated {
+	case familyRotated:
+	case familyReused:
+		// The family is already revoked, so a failed email must not turn
+		// the answer into a server error.
+		err = app.enqueueTokenReuseEmail(r, claims.ID)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
 
-	err = app.blocklist.InvalidateToken(verifiedToken.Token, verifiedToken.StandardClaims)
-	if err != nil {
-		app.serverErrorHandler(w, err)
+		app.invalidTokenHandler(w, fmt.Errorf("refresh token reused in family %s", origin))
+		return
+	default:
+		app.invalidTokenHandler(w, fmt.Errorf("unknown token family %s", origin))
 		return
 	}
 
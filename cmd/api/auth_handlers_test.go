@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"github.com/micahasowata/blog/internal/models"
 	"github.com/micahasowata/jason"
 	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,9 +35,9 @@ func TestVerifyEmail(t *testing.T) {
 	server := httptest.NewServer(app.routes())
 	defer server.Close()
 
-	token := setUpToken(t, app, user)
+	token := setUpToken(t, app, otpVerifyEmail, user)
 
-	body := fmt.Sprintf(`{"token":"%s"}`, token)
+	body := fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, token)
 
 	tests := []struct {
 		name string
@@ -54,12 +56,17 @@ func TestVerifyEmail(t *testing.T) {
 		},
 		{
 			name: "invalid body",
-			body: `{"token":"5674902"}`,
+			body: `{"email":"addam@gmail.com","token":"5674902"}`,
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "missing email",
+			body: fmt.Sprintf(`{"token":"%s"}`, token),
 			code: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "missing token",
-			body: `{"token":"567490"}`,
+			body: `{"email":"addam@gmail.com","token":"567490"}`,
 			code: http.StatusForbidden,
 		},
 		{
@@ -98,9 +105,9 @@ func TestVerifyEmail_UserNotFound(t *testing.T) {
 	server := httptest.NewServer(app.routes())
 	defer server.Close()
 
-	token := setUpToken(t, app, user)
+	token := setUpToken(t, app, otpVerifyEmail, user)
 
-	body := fmt.Sprintf(`{"token":"%s"}`, token)
+	body := fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, token)
 
 	t.Run("missing email", func(t *testing.T) {
 		req := httpexpect.Default(t, server.URL)
@@ -188,18 +195,36 @@ func TestLoginUser(t *testing.T) {
 	createdUser, err := app.models.Users.Insert(user)
 	require.Nil(t, err)
 
-	token := setUpToken(t, app, createdUser)
+	token := setUpToken(t, app, otpLogin, createdUser)
+
+	verifyToken, err := app.issueOTP(context.Background(), otpVerifyEmail, createdUser.Email)
+	require.Nil(t, err)
 
 	tests := []struct {
 		name string
 		body string
 		code int
 	}{
+		{
+			name: "other email",
+			body: fmt.Sprintf(`{"email":"mayoraddam@gmail.com","token":"%s"}`, token),
+			code: http.StatusForbidden,
+		},
+		{
+			name: "verify token",
+			body: fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, verifyToken),
+			code: http.StatusForbidden,
+		},
 		{
 			name: "valid",
-			body: fmt.Sprintf(`{"token":"%s"}`, token),
+			body: fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, token),
 			code: http.StatusOK,
 		},
+		{
+			name: "reused token",
+			body: fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, token),
+			code: http.StatusForbidden,
+		},
 		{
 			name: "bad body",
 			body: `{"password":"9LdPaiw8B"}`,
@@ -207,12 +232,12 @@ func TestLoginUser(t *testing.T) {
 		},
 		{
 			name: "invalid body",
-			body: `{"token":"5674902"}`,
+			body: `{"email":"addam@gmail.com","token":"5674902"}`,
 			code: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "missing token",
-			body: `{"token":"567490"}`,
+			body: `{"email":"addam@gmail.com","token":"567490"}`,
 			code: http.StatusForbidden,
 		},
 	}
@@ -233,6 +258,62 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+func TestLoginUser_Lockout(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	user := &models.Users{
+		ID:       xid.New().String(),
+		Name:     "addam",
+		Username: "iamaddam",
+		Email:    "addam@gmail.com",
+	}
+
+	createdUser, err := app.models.Users.Insert(user)
+	require.Nil(t, err)
+
+	token := setUpToken(t, app, otpLogin, createdUser)
+
+	wrong := "000000"
+	if token == wrong {
+		wrong = "111111"
+	}
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	req := httpexpect.Default(t, server.URL)
+
+	for i := 1; i < otpMaxEmailFailures; i++ {
+		req.POST("/v1/users/login").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithBytes([]byte(fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, wrong))).
+			Expect().
+			Status(http.StatusForbidden)
+	}
+
+	res := req.POST("/v1/users/login").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithBytes([]byte(fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, wrong))).
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	res.Header("Retry-After").IsEqual("60")
+	res.JSON().Object().Value("error").Object().Value("details").Object().Value("retry_after").IsEqual(60)
+
+	req.POST("/v1/users/login").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithBytes([]byte(fmt.Sprintf(`{"email":"addam@gmail.com","token":"%s"}`, token))).
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	exists, err := app.rclient.Exists(context.Background(), otpKey(otpLogin, "addam@gmail.com")).Result()
+	require.Nil(t, err)
+	require.Zero(t, exists)
+}
+
 func TestLogoutUser(t *testing.T) {
 	tdb := setupDB(t)
 	defer db.Clean(tdb)
@@ -282,10 +363,86 @@ func TestRefreshToken(t *testing.T) {
 	createdUser, err := app.models.Users.Insert(user)
 	require.Nil(t, err)
 
-	refreshToken, err := app.newRefreshToken(&tokenClaims{
-		ID: createdUser.ID,
+	pair, err := app.startSession(context.Background(), createdUser.ID)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	req := httpexpect.Default(t, server.URL)
+
+	rotated := req.POST("/v1/tokens/refresh").
+		WithHeader("Authorization", "Bearer "+pair["refresh_token"]).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("token_pair").Object()
+
+	refreshToken := rotated.Value("refresh_token").String().NotEmpty().Raw()
+	accessToken := rotated.Value("access_token").String().NotEmpty().Raw()
+
+	req.GET("/v1/users/me").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		Expect().
+		Status(http.StatusOK)
+
+	t.Run("reuse", func(t *testing.T) {
+		_, err := app.inspector.DeleteAllPendingTasks("default")
+		require.Nil(t, err)
+
+		req.POST("/v1/tokens/refresh").
+			WithHeader("Authorization", "Bearer "+pair["refresh_token"]).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.POST("/v1/tokens/refresh").
+			WithHeader("Authorization", "Bearer "+refreshToken).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.GET("/v1/users/me").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusForbidden)
+
+		tasks, err := app.inspector.ListPendingTasks("default")
+		require.Nil(t, err)
+
+		types := []string{}
+		for _, task := range tasks {
+			types = append(types, task.Type)
+		}
+
+		assert.Contains(t, types, typeTokenReuseEmail)
 	})
 
+	t.Run("unknown family", func(t *testing.T) {
+		refreshToken, err := app.newRefreshToken(&tokenClaims{ID: createdUser.ID})
+		require.Nil(t, err)
+
+		req.POST("/v1/tokens/refresh").
+			WithHeader("Authorization", "Bearer "+refreshToken).
+			Expect().
+			Status(http.StatusForbidden)
+	})
+}
+
+func TestLogoutUser_RevokesFamily(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	user := &models.Users{
+		ID:       xid.New().String(),
+		Name:     "addam",
+		Username: "iamaddam",
+		Email:    "addam@gmail.com",
+	}
+
+	createdUser, err := app.models.Users.Insert(user)
+	require.Nil(t, err)
+
+	pair, err := app.startSession(context.Background(), createdUser.ID)
 	require.Nil(t, err)
 
 	server := httptest.NewServer(app.routes())
@@ -293,8 +450,13 @@ func TestRefreshToken(t *testing.T) {
 
 	req := httpexpect.Default(t, server.URL)
 
-	req.POST("/v1/tokens/refresh").
-		WithHeader("Authorization", "Bearer "+refreshToken).
+	req.POST("/v1/users/logout").
+		WithHeader("Authorization", "Bearer "+pair["access_token"]).
 		Expect().
 		Status(http.StatusOK)
+
+	req.POST("/v1/tokens/refresh").
+		WithHeader("Authorization", "Bearer "+pair["refresh_token"]).
+		Expect().
+		Status(http.StatusForbidden)
 }
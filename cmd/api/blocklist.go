@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/kataras/jwt"
+	"github.com/redis/go-redis/v9"
+)
+
+// Results of rotating a refresh token.
+const (
+	familyUnknown = -1
+	familyReused  = 0
+	familyRotated = 1
+)
+
+// tokenBlocklist keeps revoked tokens in Redis, so a logout is seen by every
+// replica and survives a restart. Entries expire along with the token.
+//
+// It also tracks refresh token families. A family remembers the jti of its
+// one live refresh token, so presenting a token that was already rotated
+// gives away that it was copied, and the whole family is revoked.
+type tokenBlocklist struct {
+	rclient *redis.Client
+}
+
+// rotateFamilyScript swaps the live refresh token of a family for a new
+// one, or revokes the family when the presented token is not the live one.
+var rotateFamilyScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+if current == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+redis.call("DEL", KEYS[1])
+redis.call("SET", KEYS[2], 1, "PX", ARGV[3])
+return 0`)
+
+var _ jwt.TokenValidator = (*tokenBlocklist)(nil)
+
+func newTokenBlocklist(rclient *redis.Client) *tokenBlocklist {
+	return &tokenBlocklist{rclient: rclient}
+}
+
+// blocklistKey uses the token's jti, falling back to a hash of the token
+// itself when it has none.
+func blocklistKey(token []byte, c jwt.Claims) string {
+	if c.ID != "" {
+		return "jwt:blocked:" + c.ID
+	}
+
+	sum := sha256.Sum256(token)
+	return "jwt:blocked:" + hex.EncodeToString(sum[:])
+}
+
+// sessionClaims returns the claims our tokens nest under StdClaims, which
+// hold the jti. c, the claims at the top of the payload, is returned for
+// tokens without them.
+func sessionClaims(token []byte, c jwt.Claims) jwt.Claims {
+	unverified, err := jwt.Decode(token)
+	if err != nil {
+		return c
+	}
+
+	claims := &tokenClaims{}
+	err = unverified.Claims(&claims)
+	if err != nil || claims.StdClaims == nil {
+		return c
+	}
+
+	return *claims.StdClaims
+}
+
+// ValidateToken rejects revoked tokens. A token is also rejected when the
+// blocklist cannot be read, rather than letting a revoked token through.
+func (b *tokenBlocklist) ValidateToken(token []byte, c jwt.Claims, err error) error {
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	n, err := b.rclient.Exists(ctx, blocklistKey(token, sessionClaims(token, c))).Result()
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		return jwt.ErrBlocked
+	}
+
+	return nil
+}
+
+// InvalidateToken revokes a verified token until it expires. A token
+// without an expiry stays revoked for good.
+func (b *tokenBlocklist) InvalidateToken(ctx context.Context, token []byte, c jwt.Claims) error {
+	if len(token) == 0 {
+		return jwt.ErrMissing
+	}
+
+	var ttl time.Duration
+
+	if c.Expiry != 0 {
+		ttl = time.Until(time.Unix(c.Expiry, 0))
+		if ttl <= 0 {
+			return nil
+		}
+	}
+
+	return b.rclient.Set(ctx, blocklistKey(token, c), 1, ttl).Err()
+}
+
+func familyKey(origin string) string {
+	return "jwt:family:" + origin
+}
+
+func revokedFamilyKey(origin string) string {
+	return "jwt:family:revoked:" + origin
+}
+
+// StartFamily records the first refresh token of a family.
+func (b *tokenBlocklist) StartFamily(ctx context.Context, origin, jti string, ttl time.Duration) error {
+	return b.rclient.Set(ctx, familyKey(origin), jti, ttl).Err()
+}
+
+// RotateFamily replaces jti with next as the live token of the family. It
+// returns familyReused, after revoking the family, when jti had already been
+// rotated, and familyUnknown when the family expired or was revoked.
+func (b *tokenBlocklist) RotateFamily(ctx context.Context, origin, jti, next string, ttl time.Duration) (int, error) {
+	keys := []string{familyKey(origin), revokedFamilyKey(origin)}
+
+	return rotateFamilyScript.Run(ctx, b.rclient, keys, jti, next, ttl.Milliseconds()).Int()
+}
+
+// RevokeFamily ends a family, rejecting every token issued in it.
+func (b *tokenBlocklist) RevokeFamily(ctx context.Context, origin string, ttl time.Duration) error {
+	pipe := b.rclient.TxPipeline()
+	pipe.Del(ctx, familyKey(origin))
+	pipe.Set(ctx, revokedFamilyKey(origin), 1, ttl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (b *tokenBlocklist) FamilyRevoked(origin string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	n, err := b.rclient.Exists(ctx, revokedFamilyKey(origin)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/kataras/jwt"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklistKey(t *testing.T) {
+	app := setupApp(t, nil)
+
+	pair, _, err := app.newTokenPair(xid.New().String(), newOriginID())
+	require.Nil(t, err)
+
+	verified, err := jwt.Verify(jwt.HS256, app.config.Key, []byte(pair["access_token"]))
+	require.Nil(t, err)
+
+	claims := &tokenClaims{}
+	err = verified.Claims(&claims)
+	require.Nil(t, err)
+	require.NotNil(t, claims.StdClaims)
+	require.NotEmpty(t, claims.StdClaims.ID)
+
+	key := "jwt:blocked:" + claims.StdClaims.ID
+
+	assert.Equal(t, key, blocklistKey(verified.Token, *claims.StdClaims))
+	assert.Equal(t, key, blocklistKey(verified.Token, sessionClaims(verified.Token, verified.StandardClaims)))
+
+	assert.Equal(t, "jwt:blocked:abc", blocklistKey([]byte("token"), jwt.Claims{ID: "abc"}))
+	assert.NotEqual(t, blocklistKey([]byte("token"), jwt.Claims{}), blocklistKey([]byte("other"), jwt.Claims{}))
+	assert.NotContains(t, blocklistKey([]byte("token"), jwt.Claims{}), "token")
+}
+
+func TestTokenBlocklist(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	// Two apps stand in for two replicas sharing one Redis.
+	first := setupApp(t, tdb)
+	second := setupApp(t, tdb)
+
+	user := &models.Users{
+		ID:       xid.New().String(),
+		Name:     "addam",
+		Username: "iamaddam",
+		Email:    "addam@gmail.com",
+	}
+
+	createdUser, err := first.models.Users.Insert(user)
+	require.Nil(t, err)
+
+	firstServer := httptest.NewServer(first.routes())
+	defer firstServer.Close()
+
+	secondServer := httptest.NewServer(second.routes())
+	defer secondServer.Close()
+
+	t.Run("logout", func(t *testing.T) {
+		accessToken, err := first.newAccessToken(&tokenClaims{ID: createdUser.ID})
+		require.Nil(t, err)
+
+		httpexpect.Default(t, secondServer.URL).GET("/v1/users/me").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK)
+
+		httpexpect.Default(t, firstServer.URL).POST("/v1/users/logout").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK)
+
+		httpexpect.Default(t, secondServer.URL).GET("/v1/users/me").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusForbidden)
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		pair, err := first.startSession(context.Background(), createdUser.ID)
+		require.Nil(t, err)
+
+		refreshToken := pair["refresh_token"]
+
+		httpexpect.Default(t, firstServer.URL).POST("/v1/tokens/refresh").
+			WithHeader("Authorization", "Bearer "+refreshToken).
+			Expect().
+			Status(http.StatusOK)
+
+		httpexpect.Default(t, secondServer.URL).POST("/v1/tokens/refresh").
+			WithHeader("Authorization", "Bearer "+refreshToken).
+			Expect().
+			Status(http.StatusForbidden)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		accessToken, err := first.newAccessToken(&tokenClaims{ID: createdUser.ID})
+		require.Nil(t, err)
+
+		verified, err := jwt.Verify(jwt.HS256, first.config.Key, []byte(accessToken))
+		require.Nil(t, err)
+
+		claims := &tokenClaims{}
+		err = verified.Claims(&claims)
+		require.Nil(t, err)
+
+		err = first.blocklist.InvalidateToken(context.Background(), verified.Token, *claims.StdClaims)
+		require.Nil(t, err)
+
+		restarted := setupApp(t, tdb)
+
+		_, err = restarted.verifyJWT(accessToken)
+		assert.ErrorIs(t, err, jwt.ErrBlocked)
+
+		ttl, err := restarted.rclient.TTL(context.Background(), blocklistKey(verified.Token, *claims.StdClaims)).Result()
+		require.Nil(t, err)
+		assert.Greater(t, ttl.Hours(), 3.9)
+	})
+}
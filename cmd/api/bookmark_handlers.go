@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+// readablePost returns a post the viewer is allowed to read.
+func (app *application) readablePost(id, viewer string) (*models.Posts, error) {
+	post, err := app.models.Posts.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !post.Published && post.Author != viewer {
+		return nil, models.ErrPostNotFound
+	}
+
+	return post, nil
+}
+
+func (app *application) addBookmark(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Post string `json:"post" validate:"required"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post, err := app.readablePost(input.Post, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	bookmark, err := app.models.Bookmarks.Insert(id, post.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDuplicateBookmark):
+			app.conflictHandler(w, err)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusCreated, jason.Envelope{"bookmark": bookmark}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getBookmarks(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	app.writeBookmarks(w, id, p)
+}
+
+func (app *application) writeBookmarks(w http.ResponseWriter, reader string, p *page) {
+	bookmarks, err := app.models.Bookmarks.GetByReader(reader, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	bookmarks, metadata := paginate(p, bookmarks, bookmarkPosition)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"bookmarks": bookmarks, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) reorderBookmarks(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Posts []string `json:"posts" validate:"required,dive,required"`
+	}
+
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	err = app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	err = app.models.Bookmarks.Reorder(id, input.Posts)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidBookmarkOrder):
+			app.conflictHandler(w, err)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	// Every position may have moved, so the first page is sent back.
+	p.Cursor = nil
+
+	app.writeBookmarks(w, id, p)
+}
+
+func (app *application) removeBookmark(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	err := app.models.Bookmarks.Delete(id, chi.URLParam(r, "post"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrBookmarkNotFound):
+			app.resourceNotFoundHandler(w, models.ErrBookmarkNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"bookmark": "bookmark removed successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) setReadingProgress(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Progress *int `json:"progress" validate:"required,gte=0,lte=100"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post, err := app.readablePost(chi.URLParam(r, "id"), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	reading, err := app.models.Readings.SetProgress(id, post.ID, *input.Progress)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"reading": reading}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getContinueReading(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	readings, err := app.models.Readings.GetInProgress(id, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	readings, metadata := paginate(p, readings, readingPosition)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"readings": readings, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/jason"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarkHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, readerToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	first := setupPost(t, app, author)
+	second := setupPost(t, app, author)
+	draft := setupPost(t, app, author)
+
+	for _, id := range []string{first.ID, second.ID} {
+		_, err := app.models.Posts.Publish(id)
+		require.Nil(t, err)
+	}
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	t.Run("add", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		for _, id := range []string{first.ID, second.ID} {
+			req.POST("/v1/bookmarks").
+				WithHeader(jason.ContentType, jason.ContentTypeJSON).
+				WithHeader("Authorization", "Bearer "+readerToken).
+				WithBytes([]byte(fmt.Sprintf(`{"post": "%s"}`, id))).
+				Expect().
+				Status(http.StatusCreated)
+		}
+
+		req.POST("/v1/bookmarks").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(fmt.Sprintf(`{"post": "%s"}`, first.ID))).
+			Expect().
+			Status(http.StatusConflict)
+
+		req.POST("/v1/bookmarks").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(fmt.Sprintf(`{"post": "%s"}`, draft.ID))).
+			Expect().
+			Status(http.StatusNotFound)
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PUT("/v1/bookmarks").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(fmt.Sprintf(`{"posts": ["%s", "%s"]}`, second.ID, first.ID))).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("bookmarks").Array().Value(0).Object().Value("post").IsEqual(second.ID)
+
+		page := req.GET("/v1/bookmarks").
+			WithQuery("limit", 1).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object()
+
+		page.Value("bookmarks").Array().Value(0).Object().Value("post").IsEqual(second.ID)
+
+		next := page.Value("metadata").Object().Value("next_cursor").String().NotEmpty().Raw()
+
+		req.GET("/v1/bookmarks").
+			WithQuery("limit", 1).
+			WithQuery("cursor", next).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("bookmarks").Array().Value(0).Object().Value("post").IsEqual(first.ID)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PUT("/v1/posts/"+first.ID+"/progress").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(`{"progress": 120}`)).
+			Expect().
+			Status(http.StatusUnprocessableEntity)
+
+		req.PUT("/v1/posts/"+first.ID+"/progress").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(`{"progress": 35}`)).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("reading").Object().Value("progress").IsEqual(35)
+
+		readings := req.GET("/v1/reading").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("readings").Array()
+
+		readings.Length().IsEqual(1)
+		readings.Value(0).Object().Value("post").IsEqual(first.ID)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.DELETE("/v1/bookmarks/"+second.ID).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK)
+
+		bookmarks := req.GET("/v1/bookmarks").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("bookmarks").Array()
+
+		bookmarks.Length().IsEqual(1)
+		bookmarks.Value(0).Object().Value("position").IsEqual(1)
+		bookmarks.Value(0).Object().Value("progress").IsEqual(35)
+	})
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+)
+
+// threadComments filters the comments a viewer may see and nests replies
+// under their parents. Post authors moderate their posts and see every
+// comment, everyone else sees visible comments, tombstones and their own
+// pending comments. Replies to a comment the viewer cannot see are dropped
+// with it.
+func threadComments(post *models.Posts, comments []*models.Comments, viewer string) []*models.Comments {
+	moderator := post.Author == viewer
+
+	seen := map[string]*models.Comments{}
+	thread := []*models.Comments{}
+
+	for _, comment := range comments {
+		switch {
+		case moderator:
+		case comment.State == models.CommentVisible, comment.State == models.CommentDeleted:
+		case comment.State == models.CommentPending && comment.Author == viewer:
+		default:
+			continue
+		}
+
+		comment.Replies = []*models.Comments{}
+
+		if comment.Parent == nil {
+			seen[comment.ID] = comment
+			thread = append(thread, comment)
+			continue
+		}
+
+		parent, ok := seen[*comment.Parent]
+		if !ok {
+			continue
+		}
+
+		seen[comment.ID] = comment
+		parent.Replies = append(parent.Replies, comment)
+	}
+
+	return thread
+}
+
+func (app *application) createComment(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Body   string  `json:"body" validate:"required,lte=10000"`
+		Parent *string `json:"parent" validate:"omitempty,required"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	user, err := app.models.Users.GetByID(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if !user.Verified {
+		app.notPermittedHandler(w, errors.New("only verified users can comment"))
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if !post.Published {
+		app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		return
+	}
+
+	comment := &models.Comments{
+		ID:     xid.New().String(),
+		Post:   post.ID,
+		Author: user.ID,
+		Parent: input.Parent,
+		Body:   input.Body,
+	}
+
+	comment, err = app.models.Comments.Insert(comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrParentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrParentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	comment.Replies = []*models.Comments{}
+
+	err = app.Write(w, http.StatusCreated, jason.Envelope{"comment": comment}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getPostComments(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if !post.Published && post.Author != id {
+		app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		return
+	}
+
+	roots, err := app.models.Comments.GetThreads(post.ID, id, post.Author == id, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	roots, metadata := paginate(p, roots, commentPosition)
+
+	parents := []string{}
+	for _, root := range roots {
+		parents = append(parents, root.ID)
+	}
+
+	replies := []*models.Comments{}
+
+	if len(parents) > 0 {
+		replies, err = app.models.Comments.GetReplies(parents)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+	}
+
+	comments := threadComments(post, append(roots, replies...), id)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"comments": comments, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) updateComment(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Body string `json:"body" validate:"required,lte=10000"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	comment, err := app.models.Comments.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCommentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if comment.Author != id {
+		app.notPermittedHandler(w, errors.New("comment can only be edited by its author"))
+		return
+	}
+
+	comment.Body = input.Body
+
+	comment, err = app.models.Comments.Update(comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCommentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	comment.Replies = []*models.Comments{}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"comment": comment}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) moderateComment(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		State string `json:"state" validate:"required,oneof=visible pending hidden"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	comment, err := app.models.Comments.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCommentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(comment.Post)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("comments can only be moderated by the post author"))
+		return
+	}
+
+	comment, err = app.models.Comments.SetState(comment.ID, input.State)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCommentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	comment.Replies = []*models.Comments{}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"comment": comment}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) deleteComment(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	comment, err := app.models.Comments.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCommentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if comment.Author != id {
+		post, err := app.models.Posts.GetByID(comment.Post)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrPostNotFound):
+				app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+			default:
+				app.serverErrorHandler(w, err)
+			}
+			return
+		}
+
+		if post.Author != id {
+			app.notPermittedHandler(w, errors.New("comment can only be deleted by its author or the post author"))
+			return
+		}
+	}
+
+	err = app.models.Comments.Delete(comment.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCommentNotFound):
+			app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"comment": "comment deleted successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadComments(t *testing.T) {
+	post := &models.Posts{Author: "author"}
+
+	root := "root"
+	hidden := "hidden"
+
+	comments := []*models.Comments{
+		{ID: "root", Author: "reader", State: models.CommentVisible},
+		{ID: "reply", Author: "reader", Parent: &root, State: models.CommentVisible},
+		{ID: "hidden", Author: "reader", Parent: &root, State: models.CommentHidden},
+		{ID: "orphan", Author: "reader", Parent: &hidden, State: models.CommentVisible},
+		{ID: "pending", Author: "reader", State: models.CommentPending},
+		{ID: "deleted", Author: "reader", State: models.CommentDeleted},
+	}
+
+	tests := []struct {
+		name    string
+		viewer  string
+		roots   []string
+		replies int
+	}{
+		{
+			name:    "moderator",
+			viewer:  "author",
+			roots:   []string{"root", "pending", "deleted"},
+			replies: 2,
+		},
+		{
+			name:    "commenter",
+			viewer:  "reader",
+			roots:   []string{"root", "pending", "deleted"},
+			replies: 1,
+		},
+		{
+			name:    "stranger",
+			viewer:  "stranger",
+			roots:   []string{"root", "deleted"},
+			replies: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread := threadComments(post, comments, tt.viewer)
+
+			ids := []string{}
+			for _, comment := range thread {
+				ids = append(ids, comment.ID)
+			}
+
+			assert.Equal(t, tt.roots, ids)
+			assert.Len(t, thread[0].Replies, tt.replies)
+		})
+	}
+}
+
+func TestCommentHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	reader, readerToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+	_, unverifiedToken := setupAuthor(t, app, "iamaddam43", "addam43@gmail.com")
+
+	for _, user := range []*models.Users{author, reader} {
+		_, err := app.models.Users.VerifyEmail(user.Email)
+		require.Nil(t, err)
+	}
+
+	post := setupPost(t, app, author)
+
+	_, err := app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	var comment string
+
+	t.Run("create", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		comment = req.POST("/v1/posts/"+post.ID+"/comments").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(`{"body": "Great *post*"}`)).
+			Expect().
+			Status(http.StatusCreated).
+			JSON().Object().Value("comment").Object().Value("id").String().Raw()
+
+		req.POST("/v1/posts/"+post.ID+"/comments").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(fmt.Sprintf(`{"body": "Thanks", "parent": "%s"}`, comment))).
+			Expect().
+			Status(http.StatusCreated)
+
+		req.POST("/v1/posts/"+post.ID+"/comments").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+unverifiedToken).
+			WithBytes([]byte(`{"body": "Great post"}`)).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.POST("/v1/posts/"+post.ID+"/comments").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(`{"body": ""}`)).
+			Expect().
+			Status(http.StatusUnprocessableEntity)
+	})
+
+	t.Run("thread", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		body := req.GET("/v1/posts/"+post.ID+"/comments").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object()
+
+		comments := body.Value("comments").Array()
+		comments.Length().IsEqual(1)
+		comments.Value(0).Object().Value("replies").Array().Length().IsEqual(1)
+
+		body.Value("metadata").Object().Value("next_cursor").IsEqual("")
+	})
+
+	t.Run("moderate", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PUT("/v1/comments/"+comment+"/state").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithBytes([]byte(`{"state": "hidden"}`)).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.PUT("/v1/comments/"+comment+"/state").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(`{"state": "deleted"}`)).
+			Expect().
+			Status(http.StatusUnprocessableEntity)
+
+		req.PUT("/v1/comments/"+comment+"/state").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(`{"state": "hidden"}`)).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("comment").Object().Value("state").IsEqual(models.CommentHidden)
+
+		req.GET("/v1/posts/"+post.ID+"/comments").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("comments").Array().Length().IsEqual(0)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PATCH("/v1/comments/"+comment).
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(`{"body": "edited"}`)).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.DELETE("/v1/comments/"+comment).
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK)
+
+		req.GET("/v1/posts/"+post.ID+"/comments").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("comments").Array().Value(0).Object().Value("state").IsEqual(models.CommentDeleted)
+	})
+}
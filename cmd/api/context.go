@@ -7,3 +7,7 @@ const userID = id("userID")
 type authToken string
 
 const userToken = authToken("userToken")
+
+type claimsKey string
+
+const userClaims = claimsKey("userClaims")
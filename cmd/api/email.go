@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/hibiken/asynq"
@@ -12,10 +14,50 @@ import (
 )
 
 const (
-	typeOTPEmail   = "email:otp"
-	typeLoginEmail = "email:login"
+	typeOTPEmail        = "email:otp"
+	typeLoginEmail      = "email:login"
+	typeTokenReuseEmail = "email:token_reuse"
 )
 
+// Bulk email falls into categories that recipients can opt out of one by
+// one. Transactional email such as one time codes has no category and is
+// always sent.
+const (
+	categoryLoginAlerts = "login_alerts"
+
+	listUnsubscribePurpose = "list-unsubscribe"
+)
+
+// newsletterCategory is the category of an author's newsletter, so leaving
+// one newsletter does not leave the others.
+func newsletterCategory(author string) string {
+	return "newsletter:" + author
+}
+
+// listUnsubscribeURL returns the link that opts email out of category. It
+// works without a login and with a single POST, as RFC 8058 requires.
+func (app *application) listUnsubscribeURL(email, category string) string {
+	token := app.signToken(listUnsubscribePurpose, category+"|"+email)
+	return app.config.BaseURL + "/v1/email/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+// setListUnsubscribe adds the one click unsubscribe headers that bulk email
+// must carry.
+func (app *application) setListUnsubscribe(message *mail.Msg, link string) {
+	message.SetGenHeader(mail.HeaderListUnsubscribe, "<"+link+">")
+	message.SetGenHeader(mail.HeaderListUnsubscribePost, "List-Unsubscribe=One-Click")
+}
+
+// suppressed reports whether email opted out of category.
+func (app *application) suppressed(email, category string) (bool, error) {
+	suppressed, err := app.models.Suppressions.Suppressed(category, []string{email})
+	if err != nil {
+		return false, err
+	}
+
+	return suppressed[strings.ToLower(email)], nil
+}
+
 type otpEmailPayload struct {
 	Subject string
 	Name    string
@@ -34,15 +76,7 @@ func (app *application) newOTPEmailTask(payload otpEmailPayload) (*asynq.Task, e
 }
 
 func (app *application) sendEmail(ctx context.Context, message *mail.Msg) error {
-	client, err := mail.NewClient(app.config.SMTPHost, mail.WithPort(app.config.SMTPPort),
-		mail.WithSMTPAuth(mail.SMTPAuthPlain), mail.WithUsername(app.config.SMTPUsername),
-		mail.WithPassword(app.config.SMTPPassword))
-
-	if err != nil {
-		return err
-	}
-
-	return client.DialAndSendWithContext(ctx, message)
+	return app.mailer.Send(ctx, message)
 }
 
 func (app *application) handleOTPEmailDelivery(ctx context.Context, t *asynq.Task) error {
@@ -113,6 +147,8 @@ func (app *application) handleLoginEmailTask(ctx context.Context, t *asynq.Task)
 
 	message.Subject(fmt.Sprintf("🚨 security alert for %s 🚨", strings.ToLower(payload.Name)))
 
+	app.setListUnsubscribe(message, app.listUnsubscribeURL(payload.To, categoryLoginAlerts))
+
 	err = message.SetBodyHTMLTemplate(templates.Parse("login"), &payload)
 	if err != nil {
 		return err
@@ -120,3 +156,72 @@ func (app *application) handleLoginEmailTask(ctx context.Context, t *asynq.Task)
 
 	return app.sendEmail(ctx, message)
 }
+
+type tokenReuseEmailPayload struct {
+	To     string
+	Name   string
+	Device string
+}
+
+func (app *application) newTokenReuseEmailTask(payload tokenReuseEmailPayload) (*asynq.Task, error) {
+	p, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(typeTokenReuseEmail, p, asynq.MaxRetry(3)), nil
+}
+
+// enqueueTokenReuseEmail warns user that their session was ended because a
+// refresh token was used twice. It is a security notice, so it has no
+// category and cannot be opted out of.
+func (app *application) enqueueTokenReuseEmail(r *http.Request, id string) error {
+	user, err := app.models.Users.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	payload := tokenReuseEmailPayload{
+		To:     user.Email,
+		Name:   user.Name,
+		Device: app.getUserDeviceInfo(app.getUserAgent(r)),
+	}
+
+	task, err := app.newTokenReuseEmailTask(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.executor.EnqueueContext(r.Context(), task)
+	return err
+}
+
+func (app *application) handleTokenReuseEmailTask(ctx context.Context, t *asynq.Task) error {
+	payload := tokenReuseEmailPayload{}
+
+	err := jsoniter.Unmarshal(t.Payload(), &payload)
+	if err != nil {
+		return err
+	}
+
+	message := mail.NewMsg()
+
+	err = message.From(app.config.From)
+	if err != nil {
+		return err
+	}
+
+	err = message.To(payload.To)
+	if err != nil {
+		return err
+	}
+
+	message.Subject(fmt.Sprintf("🚨 %s, you were signed out of blog 🚨", strings.ToLower(payload.Name)))
+
+	err = message.SetBodyHTMLTemplate(templates.Parse("token_reuse"), &payload)
+	if err != nil {
+		return err
+	}
+
+	return app.sendEmail(ctx, message)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/micahasowata/jason"
+)
+
+// readUnsubscribeToken returns the category and address a list unsubscribe
+// token was signed for.
+func (app *application) readUnsubscribeToken(r *http.Request) (string, string, error) {
+	value, err := app.verifySignedToken(listUnsubscribePurpose, r.URL.Query().Get("token"))
+	if err != nil {
+		return "", "", err
+	}
+
+	category, email, ok := strings.Cut(value, "|")
+	if !ok || category == "" || email == "" {
+		return "", "", errors.New("malformed unsubscribe token")
+	}
+
+	return category, email, nil
+}
+
+// confirmUnsubscribeEmail answers someone following an unsubscribe link.
+// Mail scanners fetch links too, so a GET only shows what the token is for
+// and the opt out itself waits for the POST.
+func (app *application) confirmUnsubscribeEmail(w http.ResponseWriter, r *http.Request) {
+	category, email, err := app.readUnsubscribeToken(r)
+	if err != nil {
+		app.invalidTokenHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"unsubscribe": jason.Envelope{"email": email, "category": category}}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+// unsubscribeEmail opts an address out of one category of bulk email. The
+// signed token in the link is the only proof needed, so mail clients can
+// call it with a bare POST as RFC 8058 describes.
+func (app *application) unsubscribeEmail(w http.ResponseWriter, r *http.Request) {
+	category, email, err := app.readUnsubscribeToken(r)
+	if err != nil {
+		app.invalidTokenHandler(w, err)
+		return
+	}
+
+	err = app.models.Suppressions.Insert(email, category)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"email": "unsubscribed successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+// resubscribeEmail lifts an opt out. It takes the same token as
+// unsubscribeEmail, which only ever reaches the owner of the address, so no
+// one else can put an address back on a list.
+func (app *application) resubscribeEmail(w http.ResponseWriter, r *http.Request) {
+	category, email, err := app.readUnsubscribeToken(r)
+	if err != nil {
+		app.invalidTokenHandler(w, err)
+		return
+	}
+
+	err = app.models.Suppressions.Delete(email, category)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"email": "resubscribed successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
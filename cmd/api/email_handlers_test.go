@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmUnsubscribeEmail(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	link, err := url.Parse(app.listUnsubscribeURL("reader@gmail.com", categoryLoginAlerts))
+	require.Nil(t, err)
+
+	req := httpexpect.Default(t, server.URL)
+
+	unsubscribe := req.GET("/v1/email/unsubscribe").
+		WithQuery("token", link.Query().Get("token")).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("unsubscribe").Object()
+
+	unsubscribe.Value("email").IsEqual("reader@gmail.com")
+	unsubscribe.Value("category").IsEqual(categoryLoginAlerts)
+
+	req.GET("/v1/email/unsubscribe").
+		WithQuery("token", "invalid").
+		Expect().
+		Status(http.StatusForbidden)
+
+	suppressed, err := app.suppressed("reader@gmail.com", categoryLoginAlerts)
+	require.Nil(t, err)
+	assert.False(t, suppressed)
+}
+
+func TestUnsubscribeEmail(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	link, err := url.Parse(app.listUnsubscribeURL("reader@gmail.com", categoryLoginAlerts))
+	require.Nil(t, err)
+
+	token := link.Query().Get("token")
+
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{
+			name:  "valid",
+			token: token,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "again",
+			token: token,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "missing token",
+			token: "",
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "other purpose",
+			token: app.signToken("login", categoryLoginAlerts+"|reader@gmail.com"),
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "malformed",
+			token: app.signToken(listUnsubscribePurpose, "reader@gmail.com"),
+			code:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.POST("/v1/email/unsubscribe").
+				WithQuery("token", tt.token).
+				WithFormField("List-Unsubscribe", "One-Click").
+				Expect().
+				Status(tt.code)
+		})
+	}
+
+	suppressed, err := app.suppressed("Reader@gmail.com", categoryLoginAlerts)
+	require.Nil(t, err)
+	assert.True(t, suppressed)
+}
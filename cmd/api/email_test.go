@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -8,25 +9,26 @@ import (
 	"testing"
 	"time"
 
+	"github.com/micahasowata/blog/internal/mailer"
 	"github.com/micahasowata/blog/internal/models"
 	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func setUpToken(t *testing.T, app *application, user *models.Users) string {
-	token := app.newToken()
-
+func setUpToken(t *testing.T, app *application, purpose string, user *models.Users) string {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 	defer cancel()
 
 	err := app.rclient.FlushAll(ctx).Err()
 	require.Nil(t, err)
 
-	err = app.rclient.Set(ctx, token, user.Email, 5*time.Hour).Err()
+	token, err := app.issueOTP(ctx, purpose, user.Email)
 	require.Nil(t, err)
 
 	return token
 }
+
 func TestNewWelcomeEmailTask(t *testing.T) {
 	app := setupApp(t, nil)
 
@@ -37,7 +39,7 @@ func TestNewWelcomeEmailTask(t *testing.T) {
 		Email:    "addam@gmail.com",
 	}
 
-	token := setUpToken(t, app, user)
+	token := app.newToken()
 
 	payload := otpEmailPayload{
 		Subject: fmt.Sprintf("%s, welcome to Blog", user.Name),
@@ -52,25 +54,32 @@ func TestNewWelcomeEmailTask(t *testing.T) {
 	require.NotNil(t, task)
 }
 
+// sentEmail returns the raw text of the last message the app sent.
+func sentEmail(t *testing.T, app *application) string {
+	t.Helper()
+
+	messages := app.mailer.(*mailer.Memory).Messages()
+	require.NotEmpty(t, messages)
+
+	buf := &bytes.Buffer{}
+	_, err := messages[len(messages)-1].WriteTo(buf)
+	require.Nil(t, err)
+
+	return buf.String()
+}
+
 func TestHandleWelcomeEmailDelivery(t *testing.T) {
-	t.Skip()
 	app := setupApp(t, nil)
-	user := &models.Users{
-		ID:       xid.New().String(),
-		Name:     "addam",
-		Username: "iamadam",
-		Email:    "addam@gmail.com",
-	}
-
-	token := setUpToken(t, app, user)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	token := app.newToken()
+
 	payload := otpEmailPayload{
-		Subject: fmt.Sprintf("%s, welcome to Blog", user.Name),
-		Name:    user.Name,
-		To:      user.Email,
+		Subject: "addam, welcome to Blog",
+		Name:    "addam",
+		To:      "addam@gmail.com",
 		Token:   token,
 		Kind:    "welcome",
 	}
@@ -81,6 +90,12 @@ func TestHandleWelcomeEmailDelivery(t *testing.T) {
 	err = app.handleOTPEmailDelivery(ctx, task)
 	require.Nil(t, err)
 
+	email := sentEmail(t, app)
+
+	assert.Contains(t, email, "To: <addam@gmail.com>")
+	assert.Contains(t, email, "Subject: addam, welcome to Blog")
+	assert.Contains(t, email, token)
+	assert.NotContains(t, email, "List-Unsubscribe")
 }
 
 func TestNewLoginEmailTask(t *testing.T) {
@@ -107,26 +122,52 @@ func TestNewLoginEmailTask(t *testing.T) {
 }
 
 func TestHandleLoginEmailTask(t *testing.T) {
-	t.Skip()
 	app := setupApp(t, nil)
 
-	r := httptest.NewRequest(http.MethodPost, "/", nil)
-
-	location, err := app.userLocation(app.userIP(r))
-	require.Nil(t, err)
-
-	device := app.getUserDeviceInfo(app.getUserAgent(r))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
 	payload := loginEmailPayload{
 		To:       "addam@gmail.com",
 		Name:     "Addam",
-		Location: location,
-		Device:   device,
+		Location: "Dublin, Ireland",
+		Device:   "Chrome on Linux",
 	}
 
 	task, err := app.newLoginEmailTask(payload)
 	require.Nil(t, err)
 
-	err = app.handleLoginEmailTask(r.Context(), task)
+	err = app.handleLoginEmailTask(ctx, task)
+	require.Nil(t, err)
+
+	email := sentEmail(t, app)
+
+	assert.Contains(t, email, "To: <addam@gmail.com>")
+	assert.Contains(t, email, "Dublin, Ireland")
+	assert.Contains(t, email, "List-Unsubscribe-Post: List-Unsubscribe=One-Click")
+}
+
+func TestHandleTokenReuseEmailTask(t *testing.T) {
+	app := setupApp(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	payload := tokenReuseEmailPayload{
+		To:     "addam@gmail.com",
+		Name:   "Addam",
+		Device: "Chrome on Linux",
+	}
+
+	task, err := app.newTokenReuseEmailTask(payload)
+	require.Nil(t, err)
+
+	err = app.handleTokenReuseEmailTask(ctx, task)
 	require.Nil(t, err)
+
+	email := sentEmail(t, app)
+
+	assert.Contains(t, email, "To: <addam@gmail.com>")
+	assert.Contains(t, email, "Chrome on Linux")
+	assert.NotContains(t, email, "List-Unsubscribe")
 }
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/micahasowata/blog/internal/models"
 	"github.com/micahasowata/jason"
@@ -15,6 +17,7 @@ type errResponse struct {
 	Message string         `json:"message"`
 	Details jason.Envelope `json:"details"`
 	Cause   error          `json:"-"`
+	Headers http.Header    `json:"-"`
 }
 
 func (app *application) errorResponse(w http.ResponseWriter, e *errResponse) {
@@ -22,7 +25,7 @@ func (app *application) errorResponse(w http.ResponseWriter, e *errResponse) {
 		app.logger.Error(e.Cause.Error())
 	}
 
-	err := app.Write(w, e.Code, jason.Envelope{"error": e}, nil)
+	err := app.Write(w, e.Code, jason.Envelope{"error": e}, e.Headers)
 	if err != nil {
 		app.writeErrHandler(w, err)
 		return
@@ -138,3 +141,59 @@ func (app *application) resourceNotFoundHandler(w http.ResponseWriter, err error
 
 	app.errorResponse(w, e)
 }
+
+func (app *application) notPermittedHandler(w http.ResponseWriter, err error) {
+	e := &errResponse{
+		Code:    http.StatusForbidden,
+		Message: "action not permitted",
+		Cause:   err,
+	}
+
+	app.errorResponse(w, e)
+}
+
+func (app *application) conflictHandler(w http.ResponseWriter, err error) {
+	e := &errResponse{
+		Code:    http.StatusConflict,
+		Message: err.Error(),
+		Cause:   err,
+	}
+
+	app.errorResponse(w, e)
+}
+
+func (app *application) requestTooLargeHandler(w http.ResponseWriter, err error) {
+	e := &errResponse{
+		Code:    http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("request body must not be larger than %d bytes", app.config.UploadSize),
+		Cause:   err,
+	}
+
+	app.errorResponse(w, e)
+}
+
+func (app *application) unsupportedMediaHandler(w http.ResponseWriter, err error) {
+	e := &errResponse{
+		Code:    http.StatusUnsupportedMediaType,
+		Message: err.Error(),
+		Cause:   err,
+	}
+
+	app.errorResponse(w, e)
+}
+
+func (app *application) tooManyRequestsHandler(w http.ResponseWriter, retryAfter time.Duration, err error) {
+	seconds := ceilSeconds(retryAfter)
+
+	e := &errResponse{
+		Code:    http.StatusTooManyRequests,
+		Message: "too many requests, try again later",
+		Details: jason.Envelope{
+			"retry_after": seconds,
+		},
+		Cause:   err,
+		Headers: http.Header{"Retry-After": []string{strconv.Itoa(seconds)}},
+	}
+
+	app.errorResponse(w, e)
+}
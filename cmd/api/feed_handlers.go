@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/feeds"
+	"github.com/micahasowata/blog/internal/models"
+)
+
+const feedSize = 20
+
+// newFeed turns published posts into a feed, resolving each author's
+// username for the item links.
+func (app *application) newFeed(r *http.Request, title, link, description string, posts []*models.Posts) (*feeds.Feed, error) {
+	base := app.config.BaseURL
+
+	feed := &feeds.Feed{
+		Title:       title,
+		Link:        base + link,
+		FeedLink:    base + r.URL.Path,
+		Description: description,
+		Items:       []*feeds.Item{},
+	}
+
+	usernames := map[string]string{}
+
+	for _, post := range posts {
+		username, ok := usernames[post.Author]
+		if !ok {
+			user, err := app.models.Users.GetByID(post.Author)
+			if err != nil {
+				return nil, err
+			}
+
+			username = user.Username
+			usernames[post.Author] = username
+		}
+
+		published := post.Created
+		if post.PublishedAt != nil {
+			published = *post.PublishedAt
+		}
+
+		permalink := fmt.Sprintf("%s/v1/users/%s/posts/%s", base, username, post.Slug)
+
+		feed.Items = append(feed.Items, &feeds.Item{
+			ID:        permalink,
+			Title:     post.Title,
+			Link:      permalink,
+			Author:    username,
+			Published: published,
+			Updated:   post.Updated,
+			Content:   post.HTML,
+		})
+
+		for _, modified := range []time.Time{post.Updated, published} {
+			if modified.After(feed.Updated) {
+				feed.Updated = modified
+			}
+		}
+	}
+
+	return feed, nil
+}
+
+// writeFeed encodes a feed in the format named in the URL and serves it with
+// an ETag and a Last-Modified of its newest post, so clients can make
+// conditional requests with either. The ETag wins when a client sends both.
+func (app *application) writeFeed(w http.ResponseWriter, r *http.Request, feed *feeds.Feed) {
+	var body []byte
+	var contentType string
+	var err error
+
+	switch chi.URLParam(r, "format") {
+	case "rss":
+		body, err = feeds.RSS(feed)
+		contentType = feeds.RSSContentType
+	case "atom":
+		body, err = feeds.Atom(feed)
+		contentType = feeds.AtomContentType
+	case "json":
+		body, err = feeds.JSON(feed)
+		contentType = feeds.JSONContentType
+	default:
+		app.notFoundHandler(w, r)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	http.ServeContent(w, r, "", feed.Updated, bytes.NewReader(body))
+}
+
+func (app *application) getSiteFeed(w http.ResponseWriter, r *http.Request) {
+	posts, err := app.models.Posts.GetPublished("", feedSize)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	feed, err := app.newFeed(r, "Latest posts", "/", "The newest posts from every author", posts)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	app.writeFeed(w, r, feed)
+}
+
+func (app *application) getUserFeed(w http.ResponseWriter, r *http.Request) {
+	user, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	posts, err := app.models.Posts.GetPublished(user.ID, feedSize)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	feed, err := app.newFeed(r, user.Name, "/v1/users/"+user.Username, "Posts by "+user.Name, posts)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	app.writeFeed(w, r, feed)
+}
+
+func (app *application) getTagFeed(w http.ResponseWriter, r *http.Request) {
+	tag := chi.URLParam(r, "tag")
+
+	posts, err := app.models.Tags.GetPosts(tag, nil, feedSize)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	feed, err := app.newFeed(r, "Posts tagged "+tag, "/v1/tags/"+url.PathEscape(tag)+"/posts", "The newest posts tagged "+tag, posts)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	app.writeFeed(w, r, feed)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/feeds"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	post := setupPost(t, app, author)
+	setupPost(t, app, author)
+
+	_, err := app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	_, err = app.models.Tags.SetForPost(post.ID, []string{"go"})
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+	}{
+		{
+			name:        "site rss",
+			path:        "/feeds/rss",
+			contentType: feeds.RSSContentType,
+		},
+		{
+			name:        "author atom",
+			path:        "/feeds/users/iamaddam/atom",
+			contentType: feeds.AtomContentType,
+		},
+		{
+			name:        "tag json",
+			path:        "/feeds/tags/go/json",
+			contentType: feeds.JSONContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			res := req.GET(tt.path).
+				Expect().
+				Status(http.StatusOK)
+
+			res.Header("Content-Type").IsEqual(tt.contentType)
+			res.Body().Contains("My very first post")
+
+			etag := res.Header("ETag").NotEmpty().Raw()
+			modified := res.Header("Last-Modified").NotEmpty().Raw()
+
+			req.GET(tt.path).
+				WithHeader("If-None-Match", etag).
+				Expect().
+				Status(http.StatusNotModified)
+
+			req.GET(tt.path).
+				WithHeader("If-Modified-Since", modified).
+				Expect().
+				Status(http.StatusNotModified)
+
+			since, err := http.ParseTime(modified)
+			require.Nil(t, err)
+
+			req.GET(tt.path).
+				WithHeader("If-Modified-Since", since.Add(-time.Second).Format(http.TimeFormat)).
+				Expect().
+				Status(http.StatusOK)
+		})
+	}
+
+	t.Run("drafts excluded", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/feeds/users/iamaddam/json").
+			Expect().
+			Status(http.StatusOK).
+			JSON(httpexpect.ContentOpts{MediaType: "application/feed+json"}).Object().Value("items").Array().Length().IsEqual(1)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/feeds/xml").
+			Expect().
+			Status(http.StatusNotFound)
+	})
+
+	t.Run("unknown author", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/feeds/users/nobody/rss").
+			Expect().
+			Status(http.StatusNotFound)
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+func (app *application) followUser(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	user, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if user.ID == id {
+		app.notPermittedHandler(w, errors.New("users cannot follow themselves"))
+		return
+	}
+
+	err = app.models.Follows.Insert(id, user.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"follow": "user followed successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	user, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.models.Follows.Delete(id, user.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"follow": "user unfollowed successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getFollowers(w http.ResponseWriter, r *http.Request) {
+	app.listFollows(w, r, "followers", app.models.Follows.GetFollowers)
+}
+
+func (app *application) getFollowing(w http.ResponseWriter, r *http.Request) {
+	app.listFollows(w, r, "following", app.models.Follows.GetFollowing)
+}
+
+func (app *application) listFollows(w http.ResponseWriter, r *http.Request, key string, list func(string, *models.Cursor, int) ([]*models.Follows, error)) {
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	user, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	follows, err := list(user.ID, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	follows, metadata := paginate(p, follows, func(follow *models.Follows) *models.Cursor {
+		return &models.Cursor{Key: follow.Followed, ID: follow.ID}
+	})
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{key: follows, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getFeed(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	posts, err := app.models.Follows.GetFeed(id, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	posts, metadata := paginate(p, posts, postPosition)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"posts": posts, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
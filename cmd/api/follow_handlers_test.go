@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, authorToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, readerToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	for i := 0; i < 3; i++ {
+		post := setupPost(t, app, author)
+
+		_, err := app.models.Posts.Publish(post.ID)
+		require.Nil(t, err)
+	}
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	t.Run("follow", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PUT("/v1/users/iamaddam/follow").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK)
+
+		req.PUT("/v1/users/iamaddam/follow").
+			WithHeader("Authorization", "Bearer "+authorToken).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.PUT("/v1/users/nobody/follow").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusNotFound)
+
+		req.GET("/v1/users/iamaddam/followers").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("followers").Array().Value(0).Object().Value("username").IsEqual("iamaddam42")
+
+		req.GET("/v1/users/iamaddam42/following").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("following").Array().Length().IsEqual(1)
+	})
+
+	t.Run("feed", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		page := req.GET("/v1/feed").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithQuery("limit", 2).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object()
+
+		page.Value("posts").Array().Length().IsEqual(2)
+
+		cursor := page.Value("metadata").Object().Value("next_cursor").String().NotEmpty().Raw()
+
+		page = req.GET("/v1/feed").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithQuery("limit", 2).
+			WithQuery("cursor", cursor).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object()
+
+		page.Value("posts").Array().Length().IsEqual(1)
+		page.Value("metadata").Object().Value("next_cursor").IsEqual("")
+
+		cursor = page.Value("metadata").Object().Value("prev_cursor").String().NotEmpty().Raw()
+
+		req.GET("/v1/feed").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithQuery("limit", 2).
+			WithQuery("cursor", cursor).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("posts").Array().Length().IsEqual(2)
+
+		req.GET("/v1/feed").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			WithQuery("cursor", "%%%").
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+
+	t.Run("unfollow", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.DELETE("/v1/users/iamaddam/follow").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK)
+
+		req.GET("/v1/feed").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("posts").Array().IsEmpty()
+	})
+}
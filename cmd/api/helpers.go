@@ -2,16 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dchest/uniuri"
 	"github.com/go-playground/validator/v10"
 	ipdata "github.com/ipdata/go"
+	"github.com/micahasowata/jason"
 	"github.com/mssola/useragent"
-	"github.com/tomasen/realip"
 )
 
 func (app *application) formatValidationErr(err error) (map[string]string, error) {
@@ -33,8 +41,52 @@ func (app *application) newToken() string {
 	return uniuri.NewLenChars(6, []byte("01234567890"))
 }
 
+// clientIP returns the address of the client. X-Forwarded-For is only read
+// when the peer is a trusted proxy, and then from the right, skipping the
+// trusted proxies, so addresses a client wrote into it are never used.
+func (app *application) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !app.trustedProxy(peer) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		host = hop.Unmap().String()
+
+		if !app.trustedProxy(hop) {
+			break
+		}
+	}
+
+	return host
+}
+
+func (app *application) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, proxy := range app.config.TrustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (app *application) userIP(r *http.Request) string {
-	ip := realip.FromRequest(r)
+	ip := app.clientIP(r)
 
 	if ip == "127.0.0.1" || ip == "::1" || ip == "192.0.2.1" {
 		ip = "86.44.17.109"
@@ -85,3 +137,53 @@ func (app *application) getUserDeviceInfo(ua string) string {
 	deviceInfo := fmt.Sprintf("%s on %s", browser, os)
 	return deviceInfo
 }
+
+func (app *application) readInt(qs url.Values, key string, fallback int) (int, error) {
+	value := qs.Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &jason.Err{Code: http.StatusBadRequest, Msg: fmt.Sprintf("%s must be an integer", key)}
+	}
+
+	return i, nil
+}
+
+var errInvalidSignature = errors.New("signature is invalid")
+
+// signToken binds value to purpose with an HMAC keyed by config.Key, so a
+// link carrying the token can be trusted without looking anything up.
+func (app *application) signToken(purpose, value string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(value))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(app.signature(purpose, encoded))
+}
+
+// verifySignedToken returns the value of a token made by signToken for the
+// same purpose.
+func (app *application) verifySignedToken(purpose, token string) (string, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", errInvalidSignature
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, app.signature(purpose, encoded)) {
+		return "", errInvalidSignature
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errInvalidSignature
+	}
+
+	return string(value), nil
+}
+
+func (app *application) signature(purpose, encoded string) []byte {
+	mac := hmac.New(sha256.New, app.config.Key)
+	mac.Write([]byte(purpose + ":" + encoded))
+	return mac.Sum(nil)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"strings"
 	"testing"
 
@@ -57,6 +58,61 @@ func TestUserIP(t *testing.T) {
 	assert.Equal(t, "86.44.17.109", ip)
 }
 
+func TestClientIP(t *testing.T) {
+	app := setupApp(t, nil)
+	app.config.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		ip        string
+	}{
+		{
+			name:   "direct",
+			remote: "203.0.113.7:4000",
+			ip:     "203.0.113.7",
+		},
+		{
+			name:      "untrusted peer",
+			remote:    "203.0.113.7:4000",
+			forwarded: "198.51.100.1",
+			ip:        "203.0.113.7",
+		},
+		{
+			name:      "trusted proxy",
+			remote:    "10.0.0.2:4000",
+			forwarded: "198.51.100.1",
+			ip:        "198.51.100.1",
+		},
+		{
+			name:      "spoofed hops",
+			remote:    "10.0.0.2:4000",
+			forwarded: "1.1.1.1, 198.51.100.1, 10.0.0.3",
+			ip:        "198.51.100.1",
+		},
+		{
+			name:      "malformed",
+			remote:    "10.0.0.2:4000",
+			forwarded: "nonsense",
+			ip:        "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			assert.Equal(t, tt.ip, app.clientIP(r))
+		})
+	}
+}
+
 func TestUserLocation(t *testing.T) {
 	app := setupApp(t, nil)
 
@@ -88,3 +144,25 @@ func TestGetUserDeviceInfo(t *testing.T) {
 
 	assert.NotEmpty(t, strings.Contains(info, "Linux"))
 }
+
+func TestSignToken(t *testing.T) {
+	app := setupApp(t, nil)
+
+	token := app.signToken("unsubscribe", "cnp3vq0p2c7m5k0q4gkg")
+
+	value, err := app.verifySignedToken("unsubscribe", token)
+	require.Nil(t, err)
+	assert.Equal(t, "cnp3vq0p2c7m5k0q4gkg", value)
+
+	_, err = app.verifySignedToken("login", token)
+	assert.Equal(t, errInvalidSignature, err)
+
+	encoded, _, _ := strings.Cut(token, ".")
+	forged := app.signToken("unsubscribe", "someone-else")
+	_, sig, _ := strings.Cut(forged, ".")
+
+	for _, value := range []string{"", "nope", encoded + "." + sig, token + "x"} {
+		_, err := app.verifySignedToken("unsubscribe", value)
+		assert.Equal(t, errInvalidSignature, err)
+	}
+}
@@ -1,32 +1,50 @@
 package main
 
 import (
+	"context"
 	"time"
 
 	"github.com/kataras/jwt"
 	"github.com/rs/xid"
 )
 
+const (
+	accessTokenTTL  = 4 * time.Hour
+	refreshTokenTTL = 24 * 2 * time.Hour
+)
+
 type tokenClaims struct {
 	ID        string
 	StdClaims *jwt.Claims
 }
 
+// newOriginID starts a token family. Every refresh token rotated from a
+// login, and the access tokens issued with them, carry the same OriginID.
+func newOriginID() string {
+	return xid.New().String()[0:7] + xid.New().String()
+}
+
+// newStdClaims expires with the token it is signed into, which is what the
+// blocklist reads to know how long a revoked token has to be kept.
+func newStdClaims(subject, origin string, ttl time.Duration) *jwt.Claims {
+	return &jwt.Claims{
+		ID:        xid.New().String(),
+		OriginID:  origin,
+		NotBefore: time.Now().Unix(),
+		IssuedAt:  time.Now().Unix(),
+		Expiry:    time.Now().Add(ttl).Unix(),
+		Issuer:    "blog-be",
+		Subject:   subject,
+		Audience:  jwt.Audience{"blog-ui"},
+	}
+}
+
 func (app *application) newAccessToken(claims *tokenClaims) (string, error) {
 	if claims.StdClaims == nil {
-		claims.StdClaims = &jwt.Claims{
-			ID:        xid.New().String(),
-			OriginID:  xid.New().String()[0:7] + xid.New().String(),
-			NotBefore: time.Now().Unix(),
-			IssuedAt:  time.Now().Unix(),
-			Expiry:    time.Now().Add(3 * time.Hour).Unix(),
-			Issuer:    "blog-be",
-			Subject:   "access",
-			Audience:  jwt.Audience{"blog-ui"},
-		}
+		claims.StdClaims = newStdClaims("access", newOriginID(), accessTokenTTL)
 	}
 
-	token, err := jwt.Sign(jwt.HS256, app.config.Key, claims, jwt.MaxAge(4*time.Hour))
+	token, err := jwt.Sign(jwt.HS256, app.config.Key, claims, jwt.MaxAge(accessTokenTTL))
 	if err != nil {
 		return "", err
 	}
@@ -36,25 +54,56 @@ func (app *application) newAccessToken(claims *tokenClaims) (string, error) {
 
 func (app *application) newRefreshToken(claims *tokenClaims) (string, error) {
 	if claims.StdClaims == nil {
-		claims.StdClaims = &jwt.Claims{
-			ID:        xid.New().String(),
-			OriginID:  xid.New().String()[0:7] + xid.New().String(),
-			NotBefore: time.Now().Unix(),
-			IssuedAt:  time.Now().Unix(),
-			Expiry:    time.Now().Add(3 * time.Hour).Unix(),
-			Issuer:    "blog-be",
-			Subject:   "refresh",
-			Audience:  jwt.Audience{"blog-ui"},
-		}
+		claims.StdClaims = newStdClaims("refresh", newOriginID(), refreshTokenTTL)
 	}
 
-	token, err := jwt.Sign(jwt.HS256, app.config.Key, claims, jwt.MaxAge(24*2*time.Hour))
+	token, err := jwt.Sign(jwt.HS256, app.config.Key, claims, jwt.MaxAge(refreshTokenTTL))
 	if err != nil {
 		return "", err
 	}
 
 	return string(token), nil
 }
+
+// newTokenPair signs an access and a refresh token in the same family.
+func (app *application) newTokenPair(user, origin string) (map[string]string, *jwt.Claims, error) {
+	accessToken, err := app.newAccessToken(&tokenClaims{ID: user, StdClaims: newStdClaims("access", origin, accessTokenTTL)})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshClaims := newStdClaims("refresh", origin, refreshTokenTTL)
+
+	refreshToken, err := app.newRefreshToken(&tokenClaims{ID: user, StdClaims: refreshClaims})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair := map[string]string{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}
+
+	return pair, refreshClaims, nil
+}
+
+// startSession issues the first token pair of a new family.
+func (app *application) startSession(ctx context.Context, user string) (map[string]string, error) {
+	pair, refreshClaims, err := app.newTokenPair(user, newOriginID())
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.blocklist.StartFamily(ctx, refreshClaims.OriginID, refreshClaims.ID, refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// verifyJWT also rejects tokens whose family was revoked, which covers every
+// token issued since the login that started it.
 func (app *application) verifyJWT(token string) (*tokenClaims, error) {
 	verifiedToken, err := jwt.Verify(jwt.HS256, app.config.Key, []byte(token), app.blocklist)
 	if err != nil {
@@ -67,5 +116,16 @@ func (app *application) verifyJWT(token string) (*tokenClaims, error) {
 		return nil, err
 	}
 
+	if claims.StdClaims != nil && claims.StdClaims.OriginID != "" {
+		revoked, err := app.blocklist.FamilyRevoked(claims.StdClaims.OriginID)
+		if err != nil {
+			return nil, err
+		}
+
+		if revoked {
+			return nil, jwt.ErrBlocked
+		}
+	}
+
 	return claims, nil
 }
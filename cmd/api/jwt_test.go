@@ -2,7 +2,9 @@ package main
 
 import (
 	"testing"
+	"time"
 
+	"github.com/kataras/jwt"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +22,29 @@ func TestNewAccessToken(t *testing.T) {
 	require.NotEmpty(t, token)
 }
 
+func TestNewTokenPair(t *testing.T) {
+	app := setupApp(t, nil)
+
+	pair, refreshClaims, err := app.newTokenPair(xid.New().String(), newOriginID())
+	require.Nil(t, err)
+
+	now := time.Now()
+
+	for name, ttl := range map[string]time.Duration{"access_token": accessTokenTTL, "refresh_token": refreshTokenTTL} {
+		verified, err := jwt.Verify(jwt.HS256, app.config.Key, []byte(pair[name]))
+		require.Nil(t, err)
+
+		claims := &tokenClaims{}
+		err = verified.Claims(&claims)
+		require.Nil(t, err)
+
+		assert.Equal(t, verified.StandardClaims.Expiry, claims.StdClaims.Expiry, name)
+		assert.WithinDuration(t, now.Add(ttl), time.Unix(claims.StdClaims.Expiry, 0), 2*time.Second, name)
+	}
+
+	assert.WithinDuration(t, now.Add(refreshTokenTTL), time.Unix(refreshClaims.Expiry, 0), 2*time.Second)
+}
+
 func TestVerifyToken(t *testing.T) {
 	app := setupApp(t, nil)
 
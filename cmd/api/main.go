@@ -1,10 +1,8 @@
 package main
 
 import (
-	"context"
 	"errors"
 	"log"
-	"time"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
@@ -12,10 +10,11 @@ import (
 	en_translations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/hibiken/asynq"
 	"github.com/joho/godotenv"
-	"github.com/kataras/jwt"
 	"github.com/micahasowata/blog/internal/config"
 	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/mailer"
 	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/blog/internal/storage"
 	"github.com/micahasowata/jason"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -31,7 +30,10 @@ type application struct {
 	models     *models.Models
 	rclient    *redis.Client
 	executor   *asynq.Client
-	blocklist  *jwt.Blocklist
+	inspector  *asynq.Inspector
+	blocklist  *tokenBlocklist
+	store      storage.Store
+	mailer     mailer.Mailer
 }
 
 func main() {
@@ -68,11 +70,25 @@ func main() {
 		Addr: config.RDB,
 	})
 
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{
+		Addr: config.RDB,
+	})
+
 	rclient := redis.NewClient(&redis.Options{
 		Addr: config.RDB,
 	})
 
-	blocklist := jwt.NewBlocklistContext(context.Background(), 1*time.Hour)
+	blocklist := newTokenBlocklist(rclient)
+
+	store, err := storage.New(config)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	mail, err := mailer.New(config)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
 	app := &application{
 		Jason:      jason.New(int64(config.MaxSize), false, true),
@@ -83,7 +99,20 @@ func main() {
 		models:     models.New(db),
 		rclient:    rclient,
 		executor:   executor,
+		inspector:  inspector,
 		blocklist:  blocklist,
+		store:      store,
+		mailer:     mail,
+	}
+
+	// Posts written before bodies were rendered on save have no html yet.
+	rendered, err := app.models.Posts.RenderMissingHTML()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if rendered > 0 {
+		logger.Info("rendered posts without html", zap.Int("posts", rendered))
 	}
 
 	app.serve()
@@ -20,7 +20,7 @@ func (app *application) stack(router *chi.Mux) {
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.CleanPath)
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RequestSize(int64(app.config.MaxSize)))
+	router.Use(app.rateLimit)
 	router.MethodNotAllowed(http.HandlerFunc(app.methodNotAllowed))
 	router.NotFound(http.HandlerFunc(app.notFoundHandler))
 }
@@ -96,6 +96,7 @@ func (app *application) requireRefreshToken(next http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), userToken, token)
 		ctx = context.WithValue(ctx, userID, user.ID)
+		ctx = context.WithValue(ctx, userClaims, claims)
 
 		r = r.WithContext(ctx)
 
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/blog/internal/templates"
+	"github.com/wneessen/go-mail"
+)
+
+const (
+	typeNewsletterFanout = "newsletter:fanout"
+	typeNewsletterBatch  = "newsletter:batch"
+
+	newsletterBatchSize = 50
+)
+
+type newsletterFanoutPayload struct {
+	Post string
+}
+
+type newsletterRecipient struct {
+	ID    string
+	Email string
+}
+
+type newsletterBatchPayload struct {
+	Post       string
+	Recipients []newsletterRecipient
+}
+
+type newsletterEmail struct {
+	Author      string
+	Title       string
+	Content     template.HTML
+	Link        string
+	Unsubscribe string
+}
+
+func newsletterTaskID(post string) string {
+	return typeNewsletterFanout + ":" + post
+}
+
+func (app *application) newNewsletterFanoutTask(payload newsletterFanoutPayload) (*asynq.Task, error) {
+	p, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(typeNewsletterFanout, p, asynq.MaxRetry(5), asynq.TaskID(newsletterTaskID(payload.Post))), nil
+}
+
+func (app *application) newNewsletterBatchTask(payload newsletterBatchPayload, opts ...asynq.Option) (*asynq.Task, error) {
+	p, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(typeNewsletterBatch, p, append([]asynq.Option{asynq.MaxRetry(3)}, opts...)...), nil
+}
+
+// enqueueNewsletter schedules a just published post to be mailed to its
+// author's subscribers.
+func (app *application) enqueueNewsletter(ctx context.Context, post string) error {
+	task, err := app.newNewsletterFanoutTask(newsletterFanoutPayload{Post: post})
+	if err != nil {
+		return err
+	}
+
+	_, err = app.executor.EnqueueContext(ctx, task)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+
+	return nil
+}
+
+// handleNewsletterFanoutTask splits an author's confirmed subscribers into
+// batch tasks, leaving out those who opted out of the newsletter. Batch task
+// ids are derived from the post, so a retried fan-out does not enqueue a
+// batch twice, and the post is only marked as issued once every batch is
+// queued.
+func (app *application) handleNewsletterFanoutTask(ctx context.Context, t *asynq.Task) error {
+	payload := newsletterFanoutPayload{}
+
+	err := jsoniter.Unmarshal(t.Payload(), &payload)
+	if err != nil {
+		return err
+	}
+
+	post, err := app.models.Posts.GetByID(payload.Post)
+	if err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if !post.Published {
+		return nil
+	}
+
+	issued, err := app.models.Subscribers.Issued(post.ID)
+	if err != nil {
+		return err
+	}
+
+	if issued {
+		return nil
+	}
+
+	after := ""
+
+	for batch := 0; ; batch++ {
+		subscribers, err := app.models.Subscribers.GetConfirmed(post.Author, after, newsletterBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(subscribers) == 0 {
+			break
+		}
+
+		emails := make([]string, 0, len(subscribers))
+		for _, subscriber := range subscribers {
+			emails = append(emails, subscriber.Email)
+		}
+
+		suppressed, err := app.models.Suppressions.Suppressed(newsletterCategory(post.Author), emails)
+		if err != nil {
+			return err
+		}
+
+		recipients := []newsletterRecipient{}
+		for _, subscriber := range subscribers {
+			if !suppressed[strings.ToLower(subscriber.Email)] {
+				recipients = append(recipients, newsletterRecipient{ID: subscriber.ID, Email: subscriber.Email})
+			}
+		}
+
+		if len(recipients) == 0 {
+			if len(subscribers) < newsletterBatchSize {
+				break
+			}
+
+			after = subscribers[len(subscribers)-1].ID
+			continue
+		}
+
+		task, err := app.newNewsletterBatchTask(
+			newsletterBatchPayload{Post: post.ID, Recipients: recipients},
+			asynq.TaskID(fmt.Sprintf("%s:%s:%d", typeNewsletterBatch, post.ID, batch)),
+			asynq.Retention(24*time.Hour),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = app.executor.EnqueueContext(ctx, task)
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			return err
+		}
+
+		if len(subscribers) < newsletterBatchSize {
+			break
+		}
+
+		after = subscribers[len(subscribers)-1].ID
+	}
+
+	return app.models.Subscribers.Issue(post.ID)
+}
+
+// handleNewsletterBatchTask mails a post to a batch of subscribers. When a
+// message fails after others went out, the rest of the batch is queued as a
+// new task instead of retrying this one, so nobody gets the post twice.
+func (app *application) handleNewsletterBatchTask(ctx context.Context, t *asynq.Task) error {
+	payload := newsletterBatchPayload{}
+
+	err := jsoniter.Unmarshal(t.Payload(), &payload)
+	if err != nil {
+		return err
+	}
+
+	post, err := app.models.Posts.GetByID(payload.Post)
+	if err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	author, err := app.models.Users.GetByID(post.Author)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	for i, recipient := range payload.Recipients {
+		err = app.sendNewsletter(ctx, author, post, recipient)
+		if err == nil {
+			continue
+		}
+
+		if i == 0 {
+			return err
+		}
+
+		app.logger.Error(err.Error())
+
+		task, err := app.newNewsletterBatchTask(newsletterBatchPayload{Post: post.ID, Recipients: payload.Recipients[i:]})
+		if err != nil {
+			return err
+		}
+
+		_, err = app.executor.EnqueueContext(ctx, task)
+		return err
+	}
+
+	return nil
+}
+
+func (app *application) sendNewsletter(ctx context.Context, author *models.Users, post *models.Posts, recipient newsletterRecipient) error {
+	message := mail.NewMsg()
+
+	err := message.From(app.config.From)
+	if err != nil {
+		return err
+	}
+
+	err = message.To(recipient.Email)
+	if err != nil {
+		return err
+	}
+
+	message.Subject(post.Title)
+
+	unsubscribe := app.listUnsubscribeURL(recipient.Email, newsletterCategory(author.ID))
+	app.setListUnsubscribe(message, unsubscribe)
+
+	data := &newsletterEmail{
+		Author:      author.Name,
+		Title:       post.Title,
+		Content:     template.HTML(post.HTML),
+		Link:        fmt.Sprintf("%s/v1/users/%s/posts/%s", app.config.BaseURL, author.Username, post.Slug),
+		Unsubscribe: unsubscribe,
+	}
+
+	err = message.SetBodyHTMLTemplate(templates.Parse("newsletter"), data)
+	if err != nil {
+		return err
+	}
+
+	return app.sendEmail(ctx, message)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+// subscriptionSentKey marks that a confirmation code was mailed to an
+// address for an author's newsletter.
+func subscriptionSentKey(author, email string) string {
+	return "newsletter:sent:" + author + ":" + hashEmail(email)
+}
+
+func (app *application) subscribe(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email" validate:"required,email,lte=150"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	author, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	subscriber, err := app.models.Subscribers.Insert(author.ID, input.Email)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	suppressed, err := app.suppressed(subscriber.Email, newsletterCategory(author.ID))
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	// The response is the same whether or not the address is already
+	// confirmed, so it does not reveal who is subscribed. An address that
+	// opted out is not mailed at all. Only its owner can lift that, through
+	// the link in the mail they opted out from.
+	message := jason.Envelope{"subscription": "check your email to confirm the subscription"}
+
+	if subscriber.Confirmed || suppressed {
+		err = app.Write(w, http.StatusAccepted, message, nil)
+		if err != nil {
+			app.writeErrHandler(w, err)
+		}
+		return
+	}
+
+	// Only one code is sent per hour so the endpoint cannot be used to flood
+	// an inbox.
+	send, err := app.rclient.SetNX(r.Context(), subscriptionSentKey(author.ID, subscriber.Email), 1, 1*time.Hour).Result()
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	if send {
+		token, err := app.issueOTP(r.Context(), subscribePurpose(author.ID), subscriber.Email)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+
+		payload := otpEmailPayload{
+			Subject: "confirm your subscription to " + strings.ToLower(author.Name),
+			Name:    author.Name,
+			To:      subscriber.Email,
+			Token:   token,
+			Kind:    "subscribe",
+		}
+
+		task, err := app.newOTPEmailTask(payload)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+
+		_, err = app.executor.EnqueueContext(r.Context(), task)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+	}
+
+	err = app.Write(w, http.StatusAccepted, message, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) confirmSubscription(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email" validate:"required,email,lte=150"`
+		Token string `json:"token" validate:"required,len=6"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	author, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.consumeOTP(r.Context(), subscribePurpose(author.ID), input.Email, app.clientIP(r), input.Token)
+	if err != nil {
+		app.otpErrHandler(w, err)
+		return
+	}
+
+	subscriber, err := app.models.Subscribers.Confirm(author.ID, input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSubscriberNotFound):
+			app.invalidTokenHandler(w, err)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"subscriber": subscriber}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/jason"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	purpose := subscribePurpose(author.ID)
+
+	key := subscriptionSentKey(author.ID, "reader@gmail.com")
+	defer app.rclient.Del(ctx, key, otpKey(purpose, "reader@gmail.com"))
+
+	req := httpexpect.Default(t, server.URL)
+
+	req.POST("/v1/users/iamaddam/subscribers").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithBytes([]byte(`{"email": "reader@gmail.com"}`)).
+		Expect().
+		Status(http.StatusAccepted)
+
+	req.POST("/v1/users/iamaddam/subscribers").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithBytes([]byte(`{"email": "reader"}`)).
+		Expect().
+		Status(http.StatusUnprocessableEntity)
+
+	req.POST("/v1/users/nobody/subscribers").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithBytes([]byte(`{"email": "reader@gmail.com"}`)).
+		Expect().
+		Status(http.StatusNotFound)
+
+	sent, err := app.rclient.Exists(ctx, key).Result()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), sent)
+
+	token, err := app.issueOTP(ctx, purpose, "reader@gmail.com")
+	require.Nil(t, err)
+
+	wrong := "000000"
+	if token == wrong {
+		wrong = "111111"
+	}
+
+	req.POST("/v1/users/iamaddam/subscribers/confirm").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"email": "reader@gmail.com", "token": wrong}).
+		Expect().
+		Status(http.StatusForbidden)
+
+	subscriber := req.POST("/v1/users/iamaddam/subscribers/confirm").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"email": "reader@gmail.com", "token": token}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("subscriber").Object()
+
+	subscriber.Value("confirmed").Boolean().IsTrue()
+
+	subscriber.Value("email").IsEqual("reader@gmail.com")
+
+	link, err := url.Parse(app.listUnsubscribeURL("reader@gmail.com", newsletterCategory(author.ID)))
+	require.Nil(t, err)
+
+	req.POST("/v1/email/unsubscribe").
+		WithQuery("token", link.Query().Get("token")).
+		WithFormField("List-Unsubscribe", "One-Click").
+		Expect().
+		Status(http.StatusOK)
+
+	suppressed, err := app.suppressed("reader@gmail.com", newsletterCategory(author.ID))
+	require.Nil(t, err)
+	assert.True(t, suppressed)
+
+	t.Run("subscribe again", func(t *testing.T) {
+		err := app.rclient.Del(ctx, key).Err()
+		require.Nil(t, err)
+
+		req.POST("/v1/users/iamaddam/subscribers").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithBytes([]byte(`{"email": "reader@gmail.com"}`)).
+			Expect().
+			Status(http.StatusAccepted)
+
+		issued, err := app.rclient.Exists(ctx, otpKey(purpose, "reader@gmail.com")).Result()
+		require.Nil(t, err)
+		assert.Equal(t, int64(0), issued)
+
+		suppressed, err := app.suppressed("reader@gmail.com", newsletterCategory(author.ID))
+		require.Nil(t, err)
+		assert.True(t, suppressed)
+	})
+
+	t.Run("resubscribe", func(t *testing.T) {
+		req.POST("/v1/email/resubscribe").
+			WithQuery("token", "invalid").
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.POST("/v1/email/resubscribe").
+			WithQuery("token", link.Query().Get("token")).
+			Expect().
+			Status(http.StatusOK)
+
+		suppressed, err := app.suppressed("reader@gmail.com", newsletterCategory(author.ID))
+		require.Nil(t, err)
+		assert.False(t, suppressed)
+	})
+}
+
+func TestConfirmSubscription_Lockout(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	_, err := app.models.Subscribers.Insert(author.ID, "reader@gmail.com")
+	require.Nil(t, err)
+
+	token, err := app.issueOTP(context.Background(), subscribePurpose(author.ID), "reader@gmail.com")
+	require.Nil(t, err)
+
+	wrong := "000000"
+	if token == wrong {
+		wrong = "111111"
+	}
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	req := httpexpect.Default(t, server.URL)
+
+	// Each guess claims to come from a different client. The header is not
+	// from a trusted proxy, so every guess still counts against one address.
+	for i := 1; i < otpMaxEmailFailures; i++ {
+		req.POST("/v1/users/iamaddam/subscribers/confirm").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i)).
+			WithJSON(map[string]string{"email": "reader@gmail.com", "token": wrong}).
+			Expect().
+			Status(http.StatusForbidden)
+	}
+
+	req.POST("/v1/users/iamaddam/subscribers/confirm").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"email": "reader@gmail.com", "token": wrong}).
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	req.POST("/v1/users/iamaddam/subscribers/confirm").
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"email": "reader@gmail.com", "token": token}).
+		Expect().
+		Status(http.StatusTooManyRequests)
+}
+
+func TestHandleNewsletterFanoutTask(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	for _, email := range []string{"first@gmail.com", "second@gmail.com"} {
+		_, err := app.models.Subscribers.Insert(author.ID, email)
+		require.Nil(t, err)
+
+		_, err = app.models.Subscribers.Confirm(author.ID, email)
+		require.Nil(t, err)
+	}
+
+	post := setupPost(t, app, author)
+
+	post, err := app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	task, err := app.newNewsletterFanoutTask(newsletterFanoutPayload{Post: post.ID})
+	require.Nil(t, err)
+
+	err = app.handleNewsletterFanoutTask(ctx, task)
+	require.Nil(t, err)
+
+	batch := typeNewsletterBatch + ":" + post.ID + ":0"
+	defer app.inspector.DeleteTask("default", batch)
+
+	info, err := app.inspector.GetTaskInfo("default", batch)
+	require.Nil(t, err)
+	assert.Equal(t, typeNewsletterBatch, info.Type)
+
+	issued, err := app.models.Subscribers.Issued(post.ID)
+	require.Nil(t, err)
+	assert.True(t, issued)
+
+	err = app.handleNewsletterFanoutTask(ctx, task)
+	require.Nil(t, err)
+}
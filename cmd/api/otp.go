@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// A one time code only works for the purpose it was issued for, so a code
+// mailed to verify an address cannot be used to log in.
+const (
+	otpVerifyEmail = "verify"
+	otpLogin       = "login"
+	otpSubscribe   = "subscribe"
+)
+
+// subscribePurpose scopes a newsletter confirmation code to one author.
+func subscribePurpose(author string) string {
+	return otpSubscribe + ":" + author
+}
+
+// Wrong guesses are counted per address and per client IP. Reaching the
+// limit for an address also throws away its code. Each lockout is twice as
+// long as the one before, until the lockout history expires.
+const (
+	otpMaxEmailFailures = 5
+	otpMaxIPFailures    = 20
+	otpFailureWindow    = 1 * time.Hour
+	otpBaseLockout      = 1 * time.Minute
+	otpMaxLockout       = 24 * time.Hour
+	otpLockoutMemory    = 24 * time.Hour
+)
+
+var errInvalidOTP = errors.New("invalid or expired code")
+
+// otpLockedError is returned while an address or IP is locked out.
+type otpLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *otpLockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry in %s", e.RetryAfter)
+}
+
+// consumeOTPScript deletes a code only when the hash matches, so a code is
+// used at most once even when two requests race.
+var consumeOTPScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// otpFailureScript counts a wrong guess. Once the count reaches the limit it
+// is cleared and a lockout is set, and the lockout length in milliseconds is
+// returned.
+var otpFailureScript = redis.NewScript(`
+local failures = redis.call("INCR", KEYS[1])
+if failures == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if failures < tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+local lockouts = redis.call("INCR", KEYS[3])
+redis.call("PEXPIRE", KEYS[3], ARGV[5])
+local lockout = math.min(tonumber(ARGV[3]) * 2 ^ (lockouts - 1), tonumber(ARGV[4]))
+lockout = math.floor(lockout)
+redis.call("SET", KEYS[2], 1, "PX", lockout)
+return lockout`)
+
+// otpExpiry is how long a code stays valid.
+const otpExpiry = 5 * time.Hour
+
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(sum[:])
+}
+
+// otpKey scopes a code to its purpose and address. The address is hashed so
+// it does not show up in the keyspace.
+func otpKey(purpose, email string) string {
+	return "otp:" + purpose + ":" + hashEmail(email)
+}
+
+// otpScope names what failed attempts are counted against.
+type otpScope struct {
+	id    string
+	limit int
+}
+
+func (s otpScope) keys() []string {
+	return []string{"otp:failures:" + s.id, "otp:lock:" + s.id, "otp:lockouts:" + s.id}
+}
+
+func otpScopes(email, ip string) (otpScope, otpScope) {
+	return otpScope{id: "email:" + hashEmail(email), limit: otpMaxEmailFailures},
+		otpScope{id: "ip:" + ip, limit: otpMaxIPFailures}
+}
+
+// otpHash keys the hash of a code with the server key, since six digits
+// alone are trivial to brute force from a leaked hash.
+func (app *application) otpHash(purpose, email, code string) string {
+	return hex.EncodeToString(app.signature("otp:"+purpose, strings.ToLower(email)+"|"+code))
+}
+
+// issueOTP returns a fresh code for email, replacing any earlier one for
+// the same purpose.
+func (app *application) issueOTP(ctx context.Context, purpose, email string) (string, error) {
+	code := app.newToken()
+
+	err := app.rclient.Set(ctx, otpKey(purpose, email), app.otpHash(purpose, email, code), otpExpiry).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// consumeOTP checks code against the one issued to email and spends it. ip
+// is the address of the client making the guess, as clientIP reports it, so
+// a forged forwarding header cannot move guesses onto a fresh counter.
+func (app *application) consumeOTP(ctx context.Context, purpose, email, ip, code string) error {
+	emailScope, ipScope := otpScopes(email, ip)
+
+	pipe := app.rclient.Pipeline()
+	emailLock := pipe.PTTL(ctx, emailScope.keys()[1])
+	ipLock := pipe.PTTL(ctx, ipScope.keys()[1])
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	retryAfter := max(emailLock.Val(), ipLock.Val())
+	if retryAfter > 0 {
+		return &otpLockedError{RetryAfter: retryAfter}
+	}
+
+	keys := []string{otpKey(purpose, email)}
+
+	deleted, err := consumeOTPScript.Run(ctx, app.rclient, keys, app.otpHash(purpose, email, code)).Int()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 1 {
+		return app.rclient.Del(ctx, emailScope.keys()[0]).Err()
+	}
+
+	return app.otpFailure(ctx, purpose, email, emailScope, ipScope)
+}
+
+// otpFailure records a wrong guess against the address and the IP and
+// reports a lockout when either of them reached its limit.
+func (app *application) otpFailure(ctx context.Context, purpose, email string, emailScope, ipScope otpScope) error {
+	emailLockout, err := app.countOTPFailure(ctx, emailScope)
+	if err != nil {
+		return err
+	}
+
+	if emailLockout > 0 {
+		err = app.rclient.Del(ctx, otpKey(purpose, email)).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	ipLockout, err := app.countOTPFailure(ctx, ipScope)
+	if err != nil {
+		return err
+	}
+
+	retryAfter := max(emailLockout, ipLockout)
+	if retryAfter > 0 {
+		return &otpLockedError{RetryAfter: retryAfter}
+	}
+
+	return errInvalidOTP
+}
+
+// countOTPFailure returns how long scope is now locked out for, which is
+// zero while it is under its limit.
+func (app *application) countOTPFailure(ctx context.Context, scope otpScope) (time.Duration, error) {
+	lockout, err := otpFailureScript.Run(ctx, app.rclient, scope.keys(),
+		scope.limit,
+		otpFailureWindow.Milliseconds(),
+		otpBaseLockout.Milliseconds(),
+		otpMaxLockout.Milliseconds(),
+		otpLockoutMemory.Milliseconds(),
+	).Int64()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(lockout) * time.Millisecond, nil
+}
+
+func (app *application) otpErrHandler(w http.ResponseWriter, err error) {
+	var lockedErr *otpLockedError
+
+	switch {
+	case errors.Is(err, errInvalidOTP):
+		app.invalidTokenHandler(w, err)
+	case errors.As(err, &lockedErr):
+		app.tooManyRequestsHandler(w, lockedErr.RetryAfter, err)
+	default:
+		app.serverErrorHandler(w, err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTPKey(t *testing.T) {
+	app := setupApp(t, nil)
+
+	key := otpKey(otpLogin, "addam@gmail.com")
+
+	assert.Equal(t, key, otpKey(otpLogin, "Addam@Gmail.com"))
+	assert.NotEqual(t, key, otpKey(otpVerifyEmail, "addam@gmail.com"))
+	assert.NotEqual(t, key, otpKey(otpLogin, "mayoraddam@gmail.com"))
+	assert.NotEqual(t, otpKey(subscribePurpose("a"), "addam@gmail.com"), otpKey(subscribePurpose("b"), "addam@gmail.com"))
+	assert.NotContains(t, key, "addam")
+
+	hash := app.otpHash(otpLogin, "addam@gmail.com", "123456")
+
+	assert.Equal(t, hash, app.otpHash(otpLogin, "ADDAM@gmail.com", "123456"))
+	assert.NotEqual(t, hash, app.otpHash(otpVerifyEmail, "addam@gmail.com", "123456"))
+	assert.NotEqual(t, hash, app.otpHash(otpLogin, "addam@gmail.com", "123457"))
+	assert.NotContains(t, hash, "123456")
+}
+
+func TestOTPErrHandler(t *testing.T) {
+	app := setupApp(t, nil)
+
+	rr := httptest.NewRecorder()
+	app.otpErrHandler(rr, &otpLockedError{RetryAfter: 1500 * time.Millisecond})
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), `"retry_after":2`)
+
+	rr = httptest.NewRecorder()
+	app.otpErrHandler(rr, errInvalidOTP)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+var errInvalidCursor = &jason.Err{Code: http.StatusBadRequest, Msg: "cursor is invalid"}
+
+// page is a request for one page of a cursor paginated list.
+type page struct {
+	Limit  int `json:"limit" validate:"gte=1,lte=100"`
+	Cursor *models.Cursor
+}
+
+// encodeCursor makes a cursor opaque to clients. The direction, sort key and
+// id, followed by the rank and position when there are any, are joined and
+// base64 encoded.
+func encodeCursor(cursor *models.Cursor) string {
+	direction := "a"
+	if cursor.Before {
+		direction = "b"
+	}
+
+	value := direction + "|" + cursor.Key.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID
+	if cursor.Rank != 0 || cursor.Position != 0 {
+		value += "|" + strconv.FormatFloat(float64(cursor.Rank), 'g', -1, 32)
+	}
+
+	if cursor.Position != 0 {
+		value += "|" + strconv.Itoa(cursor.Position)
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func decodeCursor(value string) (*models.Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	parts := strings.Split(string(decoded), "|")
+	if len(parts) < 3 || len(parts) > 5 || parts[2] == "" {
+		return nil, errInvalidCursor
+	}
+
+	cursor := &models.Cursor{ID: parts[2]}
+
+	switch parts[0] {
+	case "a":
+	case "b":
+		cursor.Before = true
+	default:
+		return nil, errInvalidCursor
+	}
+
+	cursor.Key, err = time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	if len(parts) >= 4 {
+		rank, err := strconv.ParseFloat(parts[3], 32)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+
+		cursor.Rank = float32(rank)
+	}
+
+	if len(parts) == 5 {
+		cursor.Position, err = strconv.Atoi(parts[4])
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
+
+// readPage reads the limit and cursor query parameters. The limit goes
+// through the validator, so callers should hand errors to pageErrHandler.
+func (app *application) readPage(qs url.Values) (*page, error) {
+	var err error
+
+	p := &page{}
+
+	p.Limit, err = app.readInt(qs, "limit", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.validate.Struct(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if value := qs.Get("cursor"); value != "" {
+		p.Cursor, err = decodeCursor(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (app *application) pageErrHandler(w http.ResponseWriter, err error) {
+	var bodyErr *jason.Err
+
+	switch {
+	case errors.As(err, &bodyErr):
+		app.badRequestHandler(w, err)
+	default:
+		app.validationErrHandler(w, err)
+	}
+}
+
+// paginate trims items, fetched with a limit one higher than the page asks
+// for, down to the page and builds the metadata holding the cursors of the
+// pages around it. Cursors are empty when there is no page to go to.
+func paginate[T any](p *page, items []T, position func(T) *models.Cursor) ([]T, map[string]any) {
+	more := len(items) > p.Limit
+
+	if more {
+		// Rows read towards the head of the list come back in list order, so
+		// the extra one is the first.
+		if p.Cursor != nil && p.Cursor.Before {
+			items = items[1:]
+		} else {
+			items = items[:p.Limit]
+		}
+	}
+
+	metadata := map[string]any{
+		"limit":       p.Limit,
+		"next_cursor": "",
+		"prev_cursor": "",
+	}
+
+	if len(items) == 0 {
+		return items, metadata
+	}
+
+	before := p.Cursor != nil && p.Cursor.Before
+
+	if more || before {
+		next := position(items[len(items)-1])
+		metadata["next_cursor"] = encodeCursor(next)
+	}
+
+	if (more && before) || (p.Cursor != nil && !before) {
+		prev := position(items[0])
+		prev.Before = true
+		metadata["prev_cursor"] = encodeCursor(prev)
+	}
+
+	return items, metadata
+}
+
+// searchPosition is where a result sits in a list of search results.
+func searchPosition(result *models.SearchResult) *models.Cursor {
+	return &models.Cursor{Rank: result.Rank, Key: *result.Post.PublishedAt, ID: result.Post.ID}
+}
+
+// bookmarkPosition is where a bookmark sits in a reader's bookmarks.
+func bookmarkPosition(bookmark *models.Bookmarks) *models.Cursor {
+	return &models.Cursor{Position: bookmark.Position, Key: bookmark.Created, ID: bookmark.Post}
+}
+
+// readingPosition is where a post sits in a reader's continue reading list.
+func readingPosition(reading *models.Readings) *models.Cursor {
+	return &models.Cursor{Key: reading.Updated, ID: reading.Post}
+}
+
+// commentPosition is where a top level comment sits in a post's comments.
+func commentPosition(comment *models.Comments) *models.Cursor {
+	return &models.Cursor{Key: comment.Created, ID: comment.ID}
+}
+
+// draftPosition is where a post sits in its author's own posts, drafts
+// included.
+func draftPosition(post *models.Posts) *models.Cursor {
+	return &models.Cursor{Key: post.Created, ID: post.ID}
+}
+
+// postPosition is where a published post sits in a list of posts.
+func postPosition(post *models.Posts) *models.Cursor {
+	return &models.Cursor{Key: *post.PublishedAt, ID: post.ID}
+}
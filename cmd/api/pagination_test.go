@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 30, 0, 123456000, time.UTC)
+
+	for _, before := range []bool{false, true} {
+		cursor := &models.Cursor{Key: at, ID: xid.New().String(), Before: before}
+
+		decoded, err := decodeCursor(encodeCursor(cursor))
+		require.Nil(t, err)
+
+		assert.Equal(t, cursor.ID, decoded.ID)
+		assert.Equal(t, before, decoded.Before)
+		assert.True(t, at.Equal(decoded.Key))
+	}
+
+	ranked := &models.Cursor{Rank: 0.0607927, Key: at, ID: xid.New().String()}
+
+	decoded, err := decodeCursor(encodeCursor(ranked))
+	require.Nil(t, err)
+	assert.Equal(t, ranked.Rank, decoded.Rank)
+
+	positioned := &models.Cursor{Position: 4, Key: at, ID: xid.New().String(), Before: true}
+
+	decoded, err = decodeCursor(encodeCursor(positioned))
+	require.Nil(t, err)
+	assert.Equal(t, positioned.Position, decoded.Position)
+	assert.True(t, decoded.Before)
+
+	invalid := []string{
+		"%%%",
+		base64.RawURLEncoding.EncodeToString([]byte("nope")),
+		base64.RawURLEncoding.EncodeToString([]byte("a|yesterday|cnp3vq0p2c7m5k0q4gkg")),
+		base64.RawURLEncoding.EncodeToString([]byte("c|2024-03-01T10:30:00Z|cnp3vq0p2c7m5k0q4gkg")),
+		base64.RawURLEncoding.EncodeToString([]byte("a|2024-03-01T10:30:00Z|")),
+		base64.RawURLEncoding.EncodeToString([]byte("a|2024-03-01T10:30:00Z|cnp3vq0p2c7m5k0q4gkg|high")),
+		base64.RawURLEncoding.EncodeToString([]byte("a|2024-03-01T10:30:00Z|cnp3vq0p2c7m5k0q4gkg|0|first")),
+	}
+
+	for _, value := range invalid {
+		_, err := decodeCursor(value)
+		assert.Equal(t, errInvalidCursor, err)
+	}
+}
+
+func TestReadPage(t *testing.T) {
+	app := setupApp(t, nil)
+
+	p, err := app.readPage(url.Values{})
+	require.Nil(t, err)
+	assert.Equal(t, 20, p.Limit)
+	assert.Nil(t, p.Cursor)
+
+	cursor := encodeCursor(&models.Cursor{Key: time.Now(), ID: xid.New().String()})
+
+	p, err = app.readPage(url.Values{"limit": {"5"}, "cursor": {cursor}})
+	require.Nil(t, err)
+	assert.Equal(t, 5, p.Limit)
+	assert.NotNil(t, p.Cursor)
+
+	_, err = app.readPage(url.Values{"limit": {"500"}})
+	errs, err := app.formatValidationErr(err)
+	require.Nil(t, err)
+	assert.Contains(t, errs, "limit")
+
+	_, err = app.readPage(url.Values{"cursor": {"%%%"}})
+	assert.Equal(t, errInvalidCursor, err)
+}
+
+func TestPaginate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// A list of seven items, newest first.
+	list := []*models.Cursor{}
+	for i := 6; i >= 0; i-- {
+		list = append(list, &models.Cursor{Key: start.Add(time.Duration(i) * time.Minute), ID: xid.New().String()})
+	}
+
+	position := func(c *models.Cursor) *models.Cursor {
+		return &models.Cursor{Key: c.Key, ID: c.ID}
+	}
+
+	// fetch mimics a model reading limit rows away from a cursor.
+	fetch := func(cursor *models.Cursor, limit int) []*models.Cursor {
+		if cursor == nil {
+			return list[:min(limit, len(list))]
+		}
+
+		for i, item := range list {
+			if item.ID != cursor.ID {
+				continue
+			}
+
+			if cursor.Before {
+				return list[max(0, i-limit):i]
+			}
+
+			return list[i+1 : min(i+1+limit, len(list))]
+		}
+
+		return nil
+	}
+
+	read := func(value string) ([]*models.Cursor, map[string]any) {
+		p := &page{Limit: 3}
+		if value != "" {
+			var err error
+			p.Cursor, err = decodeCursor(value)
+			require.Nil(t, err)
+		}
+
+		return paginate(p, fetch(p.Cursor, p.Limit+1), position)
+	}
+
+	items, metadata := read("")
+	assert.Equal(t, list[0:3], items)
+	assert.Equal(t, "", metadata["prev_cursor"])
+	assert.Equal(t, 3, metadata["limit"])
+
+	items, metadata = read(metadata["next_cursor"].(string))
+	assert.Equal(t, list[3:6], items)
+
+	items, metadata = read(metadata["next_cursor"].(string))
+	assert.Equal(t, list[6:], items)
+	assert.Equal(t, "", metadata["next_cursor"])
+
+	items, metadata = read(metadata["prev_cursor"].(string))
+	assert.Equal(t, list[3:6], items)
+	assert.NotEqual(t, "", metadata["next_cursor"])
+
+	items, metadata = read(metadata["prev_cursor"].(string))
+	assert.Equal(t, list[0:3], items)
+	assert.Equal(t, "", metadata["prev_cursor"])
+	assert.NotEqual(t, "", metadata["next_cursor"])
+}
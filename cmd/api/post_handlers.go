@@ -0,0 +1,436 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+)
+
+func (app *application) createPost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Title string `json:"title" validate:"required,lte=250"`
+		Body  string `json:"body" validate:"required"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post := &models.Posts{
+		ID:     xid.New().String(),
+		Author: id,
+		Title:  input.Title,
+		Body:   input.Body,
+	}
+
+	post, err = app.models.Posts.Insert(post)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusCreated, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getPost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if !post.Published && post.Author != id {
+		app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		return
+	}
+
+	author, err := app.models.Users.GetByID(post.Author)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.attachSeries(post, author.Username, id)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getPostBySlug(w http.ResponseWriter, r *http.Request) {
+	user, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	slug := chi.URLParam(r, "slug")
+
+	post, err := app.models.Posts.GetBySlug(user.ID, slug)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if !post.Published {
+		app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		return
+	}
+
+	if post.Slug != slug {
+		location := fmt.Sprintf("/v1/users/%s/posts/%s", url.PathEscape(user.Username), url.PathEscape(post.Slug))
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+		return
+	}
+
+	err = app.attachSeries(post, user.Username, "")
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getUserPosts(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	posts, err := app.models.Posts.GetByAuthor(id, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	posts, metadata := paginate(p, posts, draftPosition)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"posts": posts, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) updatePost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Title *string `json:"title" validate:"omitempty,lte=250"`
+		Body  *string `json:"body" validate:"omitempty"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("post can only be updated by its author"))
+		return
+	}
+
+	if input.Title != nil {
+		post.Title = *input.Title
+	}
+
+	if input.Body != nil {
+		post.Body = *input.Body
+	}
+
+	post, err = app.models.Posts.Update(post)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) publishPost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("post can only be published by its author"))
+		return
+	}
+
+	previous := post.PublishAt
+
+	post, err = app.models.Posts.Publish(post.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.cancelPublishPostTask(post.ID, previous)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.enqueueNewsletter(r.Context(), post.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) schedulePost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		PublishAt time.Time `json:"publish_at" validate:"required,gt"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("post can only be scheduled by its author"))
+		return
+	}
+
+	if post.Published {
+		app.conflictHandler(w, errors.New("post is already published"))
+		return
+	}
+
+	previous := post.PublishAt
+
+	post, err = app.models.Posts.Schedule(post.ID, &input.PublishAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	payload := publishPostPayload{
+		ID:        post.ID,
+		PublishAt: *post.PublishAt,
+	}
+
+	err = app.schedulePublishPostTask(r.Context(), previous, payload)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) unschedulePost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("post can only be unscheduled by its author"))
+		return
+	}
+
+	if post.Published {
+		app.conflictHandler(w, errors.New("post is already published"))
+		return
+	}
+
+	previous := post.PublishAt
+
+	post, err = app.models.Posts.Schedule(post.ID, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.cancelPublishPostTask(post.ID, previous)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) deletePost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("post can only be deleted by its author"))
+		return
+	}
+
+	err = app.models.Posts.Delete(post.ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": "post deleted successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuthor(t *testing.T, app *application, username, email string) (*models.Users, string) {
+	t.Helper()
+
+	user := &models.Users{
+		ID:       xid.New().String(),
+		Name:     "addam",
+		Username: username,
+		Email:    email,
+	}
+
+	createdUser, err := app.models.Users.Insert(user)
+	require.Nil(t, err)
+
+	accessToken, err := app.newAccessToken(&tokenClaims{
+		ID: createdUser.ID,
+	})
+	require.Nil(t, err)
+
+	return createdUser, accessToken
+}
+
+func setupPost(t *testing.T, app *application, author *models.Users) *models.Posts {
+	t.Helper()
+
+	post, err := app.models.Posts.Insert(&models.Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	return post
+}
+
+func TestCreatePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	_, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{
+			name: "valid",
+			body: `{"title": "Hello world", "body": "My very first post"}`,
+			code: http.StatusCreated,
+		},
+		{
+			name: "bad body",
+			body: `{"password":"9LdPaiw8B"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid body",
+			body: `{"title": "Hello world"}`,
+			code: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.POST("/v1/posts").
+				WithHeader(jason.ContentType, jason.ContentTypeJSON).
+				WithHeader("Authorization", "Bearer "+accessToken).
+				WithBytes([]byte(tt.body)).
+				Expect().
+				Status(tt.code)
+		})
+	}
+}
+
+func TestGetPost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		id    string
+		token string
+		code  int
+	}{
+		{
+			name:  "valid",
+			id:    post.ID,
+			token: accessToken,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "draft by another user",
+			id:    post.ID,
+			token: secondToken,
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "missing post",
+			id:    xid.New().String(),
+			token: accessToken,
+			code:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.GET("/v1/posts/"+tt.id).
+				WithHeader("Authorization", "Bearer "+tt.token).
+				Expect().
+				Status(tt.code)
+		})
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		body  string
+		token string
+		code  int
+	}{
+		{
+			name:  "valid",
+			body:  `{"title": "Hello again"}`,
+			token: accessToken,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "bad body",
+			body:  `{"password":"9LdPaiw8B"}`,
+			token: accessToken,
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "not author",
+			body:  `{"title": "Hello again"}`,
+			token: secondToken,
+			code:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.PATCH("/v1/posts/"+post.ID).
+				WithHeader(jason.ContentType, jason.ContentTypeJSON).
+				WithHeader("Authorization", "Bearer "+tt.token).
+				WithBytes([]byte(tt.body)).
+				Expect().
+				Status(tt.code)
+		})
+	}
+}
+
+func TestPublishPost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	req := httpexpect.Default(t, server.URL)
+
+	req.POST("/v1/posts/"+post.ID+"/publish").
+		WithHeader("Authorization", "Bearer "+secondToken).
+		Expect().
+		Status(http.StatusForbidden)
+
+	req.POST("/v1/posts/"+post.ID+"/publish").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		Expect().
+		Status(http.StatusOK)
+
+	req.GET("/v1/posts/"+post.ID).
+		WithHeader("Authorization", "Bearer "+secondToken).
+		Expect().
+		Status(http.StatusOK)
+}
+
+func TestDeletePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	req := httpexpect.Default(t, server.URL)
+
+	req.DELETE("/v1/posts/"+post.ID).
+		WithHeader("Authorization", "Bearer "+secondToken).
+		Expect().
+		Status(http.StatusForbidden)
+
+	req.DELETE("/v1/posts/"+post.ID).
+		WithHeader("Authorization", "Bearer "+accessToken).
+		Expect().
+		Status(http.StatusOK)
+}
+
+func TestGetPostBySlug(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	_, err := app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	post.Title = "Goodbye world"
+	post, err = app.models.Posts.Update(post)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		code     int
+		location string
+	}{
+		{
+			name: "current slug",
+			path: "/v1/users/iamaddam/posts/goodbye-world",
+			code: http.StatusOK,
+		},
+		{
+			name:     "old slug",
+			path:     "/v1/users/iamaddam/posts/hello-world",
+			code:     http.StatusMovedPermanently,
+			location: "/v1/users/iamaddam/posts/goodbye-world",
+		},
+		{
+			name: "missing slug",
+			path: "/v1/users/iamaddam/posts/missing",
+			code: http.StatusNotFound,
+		},
+		{
+			name: "missing user",
+			path: "/v1/users/nobody/posts/goodbye-world",
+			code: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			rs := req.GET(tt.path).
+				WithRedirectPolicy(httpexpect.DontFollowRedirects).
+				Expect().
+				Status(tt.code)
+
+			if tt.location != "" {
+				rs.Header("Location").IsEqual(tt.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kataras/jwt"
+	"github.com/micahasowata/blog/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Routes fall into one of these groups, each with its own limit.
+const (
+	rateLimitAuth  = "auth"
+	rateLimitWrite = "write"
+	rateLimitRead  = "read"
+)
+
+// authRoutes are the route patterns of the unauthenticated endpoints that
+// send email or check one time codes, which get the tightest limit.
+var authRoutes = map[string]bool{
+	"/v1/users/register":                       true,
+	"/v1/users/verify":                         true,
+	"/v1/tokens/login":                         true,
+	"/v1/users/login":                          true,
+	"/v1/tokens/refresh":                       true,
+	"/v1/users/{username}/subscribers":         true,
+	"/v1/users/{username}/subscribers/confirm": true,
+}
+
+// gcraScript implements the generic cell rate algorithm. The key holds the
+// theoretical arrival time of the next request in milliseconds, read from
+// the Redis clock so every replica shares one budget. It returns whether
+// the request is allowed, the requests left, the milliseconds until the
+// next one is allowed and the milliseconds until the budget is full again.
+var gcraScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local interval = period / limit
+local clock = redis.call("TIME")
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allowed = new_tat - period
+if allowed > now then
+	return {0, 0, math.ceil(allowed - now), math.ceil(tat - now)}
+end
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil(new_tat - now))
+return {1, math.floor((now - allowed) / interval), 0, math.ceil(new_tat - now)}`)
+
+type rateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// routePattern returns the pattern of the route r will be served by. The
+// limiter runs before routing, so the route is looked up here.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+
+	path := rctx.RoutePath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, path) {
+		return ""
+	}
+
+	return tctx.RoutePattern()
+}
+
+// rateLimitGroup picks the limit that applies to a request for the route
+// with the given pattern.
+func (app *application) rateLimitGroup(method, pattern string) (string, config.RateLimit) {
+	switch {
+	case authRoutes[pattern]:
+		return rateLimitAuth, app.config.AuthLimit
+	case method == http.MethodGet || method == http.MethodHead:
+		return rateLimitRead, app.config.ReadLimit
+	default:
+		return rateLimitWrite, app.config.WriteLimit
+	}
+}
+
+// rateLimitClient identifies who is making the request: the user when an
+// access token is sent, and the client IP otherwise. Only the signature of
+// the token is checked, which needs no Redis lookups. Whether it was revoked
+// is left to requireAccessToken.
+func (app *application) rateLimitClient(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && token != "" {
+		verifiedToken, err := jwt.Verify(jwt.HS256, app.config.Key, []byte(token))
+		if err == nil {
+			claims := &tokenClaims{}
+
+			err = verifiedToken.Claims(&claims)
+			if err == nil && claims.ID != "" && claims.StdClaims != nil && claims.StdClaims.Subject == "access" {
+				return "user:" + claims.ID
+			}
+		}
+	}
+
+	return "ip:" + app.clientIP(r)
+}
+
+func (app *application) allowRequest(ctx context.Context, key string, limit config.RateLimit) (*rateLimitResult, error) {
+	values, err := gcraScript.Run(ctx, app.rclient, []string{key}, limit.Requests, limit.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != 4 {
+		return nil, errors.New("unexpected rate limit reply")
+	}
+
+	result := &rateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}
+
+	return result, nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// rateLimit enforces the limit of each route group and reports it in the
+// RateLimit headers. Requests are let through when Redis is unreachable, so
+// an outage there does not take the API down with it.
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group, limit := app.rateLimitGroup(r.Method, routePattern(r))
+
+		key := "ratelimit:" + group + ":" + app.rateLimitClient(r)
+
+		result, err := app.allowRequest(r.Context(), key, limit)
+		if err != nil {
+			app.logger.Error(err.Error())
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Period)))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			app.tooManyRequestsHandler(w, result.RetryAfter, nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitGroup(t *testing.T) {
+	app := setupApp(t, nil)
+
+	tests := []struct {
+		method string
+		path   string
+		group  string
+	}{
+		{method: http.MethodPost, path: "/v1/users/login", group: rateLimitAuth},
+		{method: http.MethodPost, path: "/v1/tokens/refresh", group: rateLimitAuth},
+		{method: http.MethodPost, path: "/v1/users/iamaddam/subscribers", group: rateLimitAuth},
+		{method: http.MethodPost, path: "/v1/users/iamaddam/subscribers/confirm", group: rateLimitAuth},
+		{method: http.MethodGet, path: "/v1/users/iamaddam", group: rateLimitRead},
+		{method: http.MethodGet, path: "/v1/feed", group: rateLimitRead},
+		{method: http.MethodHead, path: "/feeds/rss", group: rateLimitRead},
+		{method: http.MethodPost, path: "/v1/posts", group: rateLimitWrite},
+		{method: http.MethodDelete, path: "/v1/bookmarks/abc", group: rateLimitWrite},
+	}
+
+	routes := app.routes().(*chi.Mux)
+
+	for _, tt := range tests {
+		rctx := chi.NewRouteContext()
+		rctx.Routes = routes
+
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		group, _ := app.rateLimitGroup(r.Method, routePattern(r))
+		assert.Equal(t, tt.group, group, tt.method+" "+tt.path)
+	}
+}
+
+func TestRateLimitClient(t *testing.T) {
+	app := setupApp(t, nil)
+
+	accessToken, err := app.newAccessToken(&tokenClaims{ID: "addam"})
+	require.Nil(t, err)
+
+	refreshToken, err := app.newRefreshToken(&tokenClaims{ID: "addam"})
+	require.Nil(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, "ip:192.0.2.1", app.rateLimitClient(r))
+
+	r.Header.Set("Authorization", "Bearer "+accessToken)
+	assert.Equal(t, "user:addam", app.rateLimitClient(r))
+
+	r.Header.Set("Authorization", "Bearer "+refreshToken)
+	assert.Equal(t, "ip:192.0.2.1", app.rateLimitClient(r))
+
+	r.Header.Set("Authorization", "Bearer forged")
+	assert.Equal(t, "ip:192.0.2.1", app.rateLimitClient(r))
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := config.RateLimit{Requests: 2, Period: time.Minute}
+
+	// Two apps stand in for two replicas sharing one Redis.
+	first := setupApp(t, nil)
+	first.config.ReadLimit = limit
+
+	second := setupApp(t, nil)
+	second.config.ReadLimit = limit
+
+	err := first.rclient.FlushAll(context.Background()).Err()
+	require.Nil(t, err)
+
+	firstServer := httptest.NewServer(first.routes())
+	defer firstServer.Close()
+
+	secondServer := httptest.NewServer(second.routes())
+	defer secondServer.Close()
+
+	res := httpexpect.Default(t, firstServer.URL).GET("/nothing").
+		Expect().
+		Status(http.StatusNotFound)
+
+	res.Header("RateLimit-Limit").IsEqual("2")
+	res.Header("RateLimit-Remaining").IsEqual("1")
+	res.Header("RateLimit-Policy").IsEqual("2;w=60")
+
+	httpexpect.Default(t, secondServer.URL).GET("/nothing").
+		Expect().
+		Status(http.StatusNotFound).
+		Header("RateLimit-Remaining").IsEqual("0")
+
+	res = httpexpect.Default(t, secondServer.URL).GET("/nothing").
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	res.Header("RateLimit-Remaining").IsEqual("0")
+	res.Header("Retry-After").IsEqual("30")
+
+	httpexpect.Default(t, firstServer.URL).POST("/nothing").
+		Expect().
+		Status(http.StatusNotFound)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+// checkReactionTarget makes sure the viewer can see the post or comment being
+// reacted to.
+func (app *application) checkReactionTarget(kind, target, viewer string) error {
+	if kind == models.ReactionComment {
+		comment, err := app.models.Comments.GetByID(target)
+		if err != nil {
+			return err
+		}
+
+		if comment.State != models.CommentVisible {
+			return models.ErrCommentNotFound
+		}
+
+		target = comment.Post
+	}
+
+	post, err := app.models.Posts.GetByID(target)
+	if err != nil {
+		return err
+	}
+
+	if !post.Published && post.Author != viewer {
+		return models.ErrPostNotFound
+	}
+
+	return nil
+}
+
+func (app *application) reactionTargetErrHandler(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrPostNotFound):
+		app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+	case errors.Is(err, models.ErrCommentNotFound):
+		app.resourceNotFoundHandler(w, models.ErrCommentNotFound)
+	default:
+		app.serverErrorHandler(w, err)
+	}
+}
+
+func (app *application) setReaction(w http.ResponseWriter, r *http.Request, kind string, add bool) {
+	id := r.Context().Value(userID).(string)
+
+	target := chi.URLParam(r, "id")
+	emoji := chi.URLParam(r, "emoji")
+
+	if !isReactionEmoji(emoji) {
+		app.badRequestHandler(w, &jason.Err{Code: http.StatusBadRequest, Msg: "unsupported reaction"})
+		return
+	}
+
+	err := app.checkReactionTarget(kind, target, id)
+	if err != nil {
+		app.reactionTargetErrHandler(w, err)
+		return
+	}
+
+	if add {
+		err = app.react(r.Context(), kind, target, emoji, id)
+	} else {
+		err = app.unreact(r.Context(), kind, target, emoji, id)
+	}
+
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	summary, err := app.reactionSummary(r.Context(), kind, target, id)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"reactions": summary}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getReactions(w http.ResponseWriter, r *http.Request, kind string) {
+	id := r.Context().Value(userID).(string)
+
+	target := chi.URLParam(r, "id")
+
+	err := app.checkReactionTarget(kind, target, id)
+	if err != nil {
+		app.reactionTargetErrHandler(w, err)
+		return
+	}
+
+	summary, err := app.reactionSummary(r.Context(), kind, target, id)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"reactions": summary}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) reactToPost(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, models.ReactionPost, true)
+}
+
+func (app *application) unreactToPost(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, models.ReactionPost, false)
+}
+
+func (app *application) getPostReactions(w http.ResponseWriter, r *http.Request) {
+	app.getReactions(w, r, models.ReactionPost)
+}
+
+func (app *application) reactToComment(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, models.ReactionComment, true)
+}
+
+func (app *application) unreactToComment(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, models.ReactionComment, false)
+}
+
+func (app *application) getCommentReactions(w http.ResponseWriter, r *http.Request) {
+	app.getReactions(w, r, models.ReactionComment)
+}
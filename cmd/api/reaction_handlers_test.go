@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, readerToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+	draft := setupPost(t, app, author)
+
+	_, err := app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	t.Run("react", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		for _, token := range []string{accessToken, readerToken, readerToken} {
+			req.PUT("/v1/posts/"+post.ID+"/reactions/clap").
+				WithHeader("Authorization", "Bearer "+token).
+				Expect().
+				Status(http.StatusOK)
+		}
+
+		reactions := req.GET("/v1/posts/"+post.ID+"/reactions").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("reactions").Object()
+
+		reactions.Value("counts").Object().Value("clap").IsEqual(2)
+		reactions.Value("counts").Object().Value("like").IsEqual(0)
+		reactions.Value("reacted").Array().IsEqual([]string{"clap"})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PUT("/v1/posts/"+post.ID+"/reactions/angry").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusBadRequest)
+
+		req.PUT("/v1/posts/"+draft.ID+"/reactions/clap").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusNotFound)
+	})
+
+	t.Run("unreact", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		reactions := req.DELETE("/v1/posts/"+post.ID+"/reactions/clap").
+			WithHeader("Authorization", "Bearer "+readerToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("reactions").Object()
+
+		reactions.Value("counts").Object().Value("clap").IsEqual(1)
+		reactions.Value("reacted").Array().IsEmpty()
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		err := app.handleFlushReactionsTask(ctx, app.newFlushReactionsTask())
+		require.Nil(t, err)
+
+		reactions, err := app.models.Reactions.GetReactions(models.ReactionPost, post.ID)
+		require.Nil(t, err)
+
+		assert.Len(t, reactions["clap"], 1)
+
+		ttl, err := app.rclient.PTTL(ctx, reactionCountsKey(models.ReactionPost, post.ID)).Result()
+		require.Nil(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+
+		usersTTL, err := app.rclient.PTTL(ctx, reactionUsersKey(models.ReactionPost, post.ID, "clap")).Result()
+		require.Nil(t, err)
+		assert.Greater(t, usersTTL, ttl)
+	})
+}
+
+func TestSeedReactions(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	reader, _ := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	keys := []string{reactionCountsKey(models.ReactionPost, post.ID)}
+	for _, emoji := range reactionEmojis {
+		keys = append(keys, reactionUsersKey(models.ReactionPost, post.ID, emoji))
+	}
+
+	defer app.rclient.Del(ctx, keys...)
+
+	err := app.models.Reactions.SetReactions(models.ReactionPost, post.ID, map[string][]string{"clap": {author.ID}})
+	require.Nil(t, err)
+
+	// Reacting before anything was read seeds the target first, so the
+	// stored reaction is counted once and the new one on top of it.
+	err = app.react(ctx, models.ReactionPost, post.ID, "clap", reader.ID)
+	require.Nil(t, err)
+
+	err = app.seedReactions(ctx, models.ReactionPost, post.ID)
+	require.Nil(t, err)
+
+	counts, err := app.reactionCounts(ctx, models.ReactionPost, post.ID)
+	require.Nil(t, err)
+
+	users, err := app.rclient.SCard(ctx, reactionUsersKey(models.ReactionPost, post.ID, "clap")).Result()
+	require.Nil(t, err)
+
+	assert.Equal(t, 2, counts["clap"])
+	assert.Equal(t, int64(counts["clap"]), users)
+}
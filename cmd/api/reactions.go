@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const typeFlushReactions = "reactions:flush"
+
+// reactionsDirtyKey holds the targets whose counts changed since the last
+// flush to Postgres.
+const reactionsDirtyKey = "reactions:dirty"
+
+var reactionEmojis = []string{"like", "love", "clap", "laugh", "insightful"}
+
+// reactionsUnseeded is what the react scripts return when the counts of a
+// target are not in Redis yet and have to be seeded first.
+const reactionsUnseeded = -1
+
+// Reactions that reached Postgres expire from Redis a while after they were
+// seeded or flushed. The user sets outlive the counts hash, so while the
+// counts are there the users are too, and a missing hash is what makes a
+// target get seeded again.
+const (
+	reactionCountsTTL = 24 * time.Hour
+	reactionUsersTTL  = reactionCountsTTL + time.Minute
+)
+
+// seedReactionsScript copies who reacted from Postgres into Redis unless the
+// counts are already there. KEYS[1] is the counts hash and the rest are the
+// user sets, one per emoji. ARGV starts with the TTLs of the hash and the
+// sets in milliseconds, then holds each emoji followed by a JSON array of
+// its users, in the order of the sets. Checking and writing in one script
+// keeps a concurrent reaction from being counted twice.
+var seedReactionsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+for i = 2, #KEYS do
+	local emoji = ARGV[2 * i - 1]
+	local users = cjson.decode(ARGV[2 * i])
+	redis.call("DEL", KEYS[i])
+	for j = 1, #users, 1000 do
+		redis.call("SADD", KEYS[i], unpack(users, j, math.min(j + 999, #users)))
+	end
+	redis.call("PEXPIRE", KEYS[i], ARGV[2])
+	redis.call("HSET", KEYS[1], emoji, #users)
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[1])
+return 1`)
+
+// The react scripts take the user set of the emoji, the counts hash, the
+// dirty set and then every user set of the target. A change keeps all of
+// them from expiring until it has been flushed.
+var reactScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	return -1
+end
+if redis.call("SADD", KEYS[1], ARGV[1]) == 1 then
+	redis.call("HINCRBY", KEYS[2], ARGV[2], 1)
+	redis.call("SADD", KEYS[3], ARGV[3])
+	redis.call("PERSIST", KEYS[2])
+	for i = 4, #KEYS do
+		redis.call("PERSIST", KEYS[i])
+	end
+end
+return 0`)
+
+var unreactScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	return -1
+end
+if redis.call("SREM", KEYS[1], ARGV[1]) == 1 then
+	redis.call("HINCRBY", KEYS[2], ARGV[2], -1)
+	redis.call("SADD", KEYS[3], ARGV[3])
+	redis.call("PERSIST", KEYS[2])
+	for i = 4, #KEYS do
+		redis.call("PERSIST", KEYS[i])
+	end
+end
+return 0`)
+
+// expireReactionsScript lets a flushed target expire, unless it changed
+// again while it was being flushed. KEYS[1] is the dirty set, KEYS[2] the
+// counts hash and the rest the user sets. ARGV holds the target and the
+// TTLs of the hash and the sets in milliseconds.
+var expireReactionsScript = redis.NewScript(`
+if redis.call("SISMEMBER", KEYS[1], ARGV[1]) == 1 then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[2], ARGV[2])
+for i = 3, #KEYS do
+	redis.call("PEXPIRE", KEYS[i], ARGV[3])
+end
+return 1`)
+
+type reactionSummary struct {
+	Counts  map[string]int `json:"counts"`
+	Reacted []string       `json:"reacted"`
+}
+
+func isReactionEmoji(emoji string) bool {
+	for _, e := range reactionEmojis {
+		if e == emoji {
+			return true
+		}
+	}
+
+	return false
+}
+
+func reactionTarget(kind, target string) string {
+	return kind + ":" + target
+}
+
+func reactionCountsKey(kind, target string) string {
+	return "reactions:" + reactionTarget(kind, target)
+}
+
+func reactionUsersKey(kind, target, emoji string) string {
+	return reactionCountsKey(kind, target) + ":" + emoji
+}
+
+// reactionUsersKeys returns the user sets of every emoji of a target.
+func reactionUsersKeys(kind, target string) []string {
+	keys := []string{}
+	for _, emoji := range reactionEmojis {
+		keys = append(keys, reactionUsersKey(kind, target, emoji))
+	}
+
+	return keys
+}
+
+func (app *application) newFlushReactionsTask() *asynq.Task {
+	return asynq.NewTask(typeFlushReactions, nil, asynq.MaxRetry(0), asynq.Unique(time.Minute))
+}
+
+// seedReactions loads who reacted to a post or comment from the last flush
+// into Redis, unless another request already did.
+func (app *application) seedReactions(ctx context.Context, kind, target string) error {
+	stored, err := app.models.Reactions.GetReactions(kind, target)
+	if err != nil {
+		return err
+	}
+
+	keys := append([]string{reactionCountsKey(kind, target)}, reactionUsersKeys(kind, target)...)
+	args := []any{reactionCountsTTL.Milliseconds(), reactionUsersTTL.Milliseconds()}
+
+	for _, emoji := range reactionEmojis {
+		users := stored[emoji]
+		if users == nil {
+			users = []string{}
+		}
+
+		encoded, err := json.Marshal(users)
+		if err != nil {
+			return err
+		}
+
+		args = append(args, emoji, string(encoded))
+	}
+
+	return seedReactionsScript.Run(ctx, app.rclient, keys, args...).Err()
+}
+
+// reactionCounts returns the live counts of a post or comment, seeding them
+// and who reacted from the last flush when Redis does not have them.
+func (app *application) reactionCounts(ctx context.Context, kind, target string) (map[string]int, error) {
+	key := reactionCountsKey(kind, target)
+
+	values, err := app.rclient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		err = app.seedReactions(ctx, kind, target)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err = app.rclient.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	counts := map[string]int{}
+
+	for _, emoji := range reactionEmojis {
+		count, err := strconv.Atoi(values[emoji])
+		if err != nil {
+			count = 0
+		}
+
+		counts[emoji] = count
+	}
+
+	return counts, nil
+}
+
+// runReactScript runs script, seeding the target first when it is not in
+// Redis yet.
+func (app *application) runReactScript(ctx context.Context, script *redis.Script, kind, target, emoji, user string) error {
+	keys := []string{
+		reactionUsersKey(kind, target, emoji),
+		reactionCountsKey(kind, target),
+		reactionsDirtyKey,
+	}
+
+	keys = append(keys, reactionUsersKeys(kind, target)...)
+
+	for attempt := 0; attempt < 3; attempt++ {
+		result, err := script.Run(ctx, app.rclient, keys, user, emoji, reactionTarget(kind, target)).Int()
+		if err != nil {
+			return err
+		}
+
+		if result != reactionsUnseeded {
+			return nil
+		}
+
+		err = app.seedReactions(ctx, kind, target)
+		if err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("reactions to %s were not seeded", reactionTarget(kind, target))
+}
+
+func (app *application) react(ctx context.Context, kind, target, emoji, user string) error {
+	return app.runReactScript(ctx, reactScript, kind, target, emoji, user)
+}
+
+func (app *application) unreact(ctx context.Context, kind, target, emoji, user string) error {
+	return app.runReactScript(ctx, unreactScript, kind, target, emoji, user)
+}
+
+func (app *application) reactionSummary(ctx context.Context, kind, target, viewer string) (*reactionSummary, error) {
+	counts, err := app.reactionCounts(ctx, kind, target)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := app.rclient.Pipeline()
+
+	cmds := map[string]*redis.BoolCmd{}
+	for _, emoji := range reactionEmojis {
+		cmds[emoji] = pipe.SIsMember(ctx, reactionUsersKey(kind, target, emoji), viewer)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &reactionSummary{
+		Counts:  counts,
+		Reacted: []string{},
+	}
+
+	for _, emoji := range reactionEmojis {
+		if cmds[emoji].Val() {
+			summary.Reacted = append(summary.Reacted, emoji)
+		}
+	}
+
+	return summary, nil
+}
+
+// handleFlushReactionsTask copies who reacted to every target that changed
+// since the last run from Redis to Postgres.
+func (app *application) handleFlushReactionsTask(ctx context.Context, t *asynq.Task) error {
+	for {
+		members, err := app.rclient.SPopN(ctx, reactionsDirtyKey, 100).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(members) == 0 {
+			return nil
+		}
+
+		for i, member := range members {
+			kind, target, _ := strings.Cut(member, ":")
+
+			err = app.flushReactions(ctx, kind, target)
+			if err != nil {
+				app.rclient.SAdd(ctx, reactionsDirtyKey, members[i:])
+				return err
+			}
+		}
+	}
+}
+
+func (app *application) flushReactions(ctx context.Context, kind, target string) error {
+	pipe := app.rclient.Pipeline()
+
+	cmds := map[string]*redis.StringSliceCmd{}
+	for _, emoji := range reactionEmojis {
+		cmds[emoji] = pipe.SMembers(ctx, reactionUsersKey(kind, target, emoji))
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	reactions := map[string][]string{}
+
+	for emoji, cmd := range cmds {
+		reactions[emoji] = cmd.Val()
+	}
+
+	err = app.models.Reactions.SetReactions(kind, target, reactions)
+	if err != nil {
+		return err
+	}
+
+	// Postgres has the reactions now, so Redis only keeps them while they
+	// are in use.
+	keys := append([]string{reactionsDirtyKey, reactionCountsKey(kind, target)}, reactionUsersKeys(kind, target)...)
+	args := []any{reactionTarget(kind, target), reactionCountsTTL.Milliseconds(), reactionUsersTTL.Milliseconds()}
+
+	return expireReactionsScript.Run(ctx, app.rclient, keys, args...).Err()
+}
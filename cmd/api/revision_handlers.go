@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/diff"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+func (app *application) getPostRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("revisions can only be viewed by the post author"))
+		return
+	}
+
+	revisions, err := app.models.Revisions.GetAllByPost(post.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"revisions": revisions}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getPostRevision(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("revisions can only be viewed by the post author"))
+		return
+	}
+
+	revision, err := app.models.Revisions.GetByID(post.ID, chi.URLParam(r, "revision"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRevisionNotFound):
+			app.resourceNotFoundHandler(w, models.ErrRevisionNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"revision": revision}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) diffPostRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	input := struct {
+		From string `validate:"required"`
+		To   string `validate:"required"`
+	}{
+		From: r.URL.Query().Get("from"),
+		To:   r.URL.Query().Get("to"),
+	}
+
+	err := app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("revisions can only be viewed by the post author"))
+		return
+	}
+
+	revisions := make([]*models.Revisions, 0, 2)
+
+	for _, revisionID := range []string{input.From, input.To} {
+		revision, err := app.models.Revisions.GetByID(post.ID, revisionID)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrRevisionNotFound):
+				app.resourceNotFoundHandler(w, models.ErrRevisionNotFound)
+			default:
+				app.serverErrorHandler(w, err)
+			}
+			return
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	from, to := revisions[0], revisions[1]
+
+	changes := map[string]any{
+		"from":  from.ID,
+		"to":    to.ID,
+		"title": diff.Lines(from.Title, to.Title),
+		"body":  diff.Lines(from.Body, to.Body),
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"diff": changes}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) restorePostRevision(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("revisions can only be restored by the post author"))
+		return
+	}
+
+	revision, err := app.models.Revisions.GetByID(post.ID, chi.URLParam(r, "revision"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRevisionNotFound):
+			app.resourceNotFoundHandler(w, models.ErrRevisionNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	post.Title = revision.Title
+	post.Body = revision.Body
+
+	post, err = app.models.Posts.Update(post)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"post": post}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRevisionHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	post.Body = "My second draft"
+	post, err := app.models.Posts.Update(post)
+	require.Nil(t, err)
+
+	revisions, err := app.models.Revisions.GetAllByPost(post.ID)
+	require.Nil(t, err)
+	require.Len(t, revisions, 2)
+
+	latest, first := revisions[0], revisions[1]
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	t.Run("list", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/v1/posts/"+post.ID+"/revisions").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("revisions").Array().Length().IsEqual(2)
+
+		req.GET("/v1/posts/"+post.ID+"/revisions").
+			WithHeader("Authorization", "Bearer "+secondToken).
+			Expect().
+			Status(http.StatusForbidden)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/v1/posts/"+post.ID+"/revisions/"+first.ID).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK)
+
+		req.GET("/v1/posts/"+post.ID+"/revisions/missing").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusNotFound)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/v1/posts/"+post.ID+"/revisions/diff").
+			WithQuery("from", first.ID).
+			WithQuery("to", latest.ID).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK)
+
+		req.GET("/v1/posts/"+post.ID+"/revisions/diff").
+			WithQuery("from", first.ID).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusUnprocessableEntity)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.POST("/v1/posts/"+post.ID+"/revisions/"+first.ID+"/restore").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("post").Object().Value("body").IsEqual(first.Body)
+
+		revisions, err := app.models.Revisions.GetAllByPost(post.ID)
+		require.Nil(t, err)
+		require.Len(t, revisions, 3)
+	})
+}
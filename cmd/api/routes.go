@@ -4,21 +4,94 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/hibiken/asynq"
+	"github.com/micahasowata/blog/internal/storage"
 )
 
 func (app *application) routes() http.Handler {
 	router := chi.NewRouter()
 	app.stack(router)
-	router.Post("/v1/users/register", app.registerUser)
-	router.Post("/v1/users/verify", app.verifyEmail)
-	router.Post("/v1/tokens/login", app.createLoginToken)
-	router.Post("/v1/users/login", app.loginUser)
-	router.With(app.requireAccessToken).Post("/v1/users/logout", app.logoutUser)
-	router.With(app.requireRefreshToken).Post("/v1/tokens/refresh", app.refreshToken)
-	router.With(app.requireAccessToken).Get("/v1/users/me", app.getUserProfile)
-	router.With(app.requireAccessToken).Patch("/v1/users/update", app.updateUserProfile)
-	router.With(app.requireAccessToken).Delete("/v1/users/delete", app.deleteUserProfile)
+	router.Group(func(router chi.Router) {
+		router.Use(middleware.RequestSize(int64(app.config.MaxSize)))
+		router.Post("/v1/users/register", app.registerUser)
+		router.Post("/v1/users/verify", app.verifyEmail)
+		router.Post("/v1/tokens/login", app.createLoginToken)
+		router.Post("/v1/users/login", app.loginUser)
+		router.With(app.requireAccessToken).Post("/v1/users/logout", app.logoutUser)
+		router.With(app.requireRefreshToken).Post("/v1/tokens/refresh", app.refreshToken)
+		router.With(app.requireAccessToken).Get("/v1/users/me", app.getUserProfile)
+		router.With(app.requireAccessToken).Patch("/v1/users/update", app.updateUserProfile)
+		router.With(app.requireAccessToken).Delete("/v1/users/delete", app.deleteUserProfile)
+		router.With(app.requireAccessToken).Post("/v1/posts", app.createPost)
+		router.With(app.requireAccessToken).Get("/v1/posts", app.getUserPosts)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}", app.getPost)
+		router.With(app.requireAccessToken).Patch("/v1/posts/{id}", app.updatePost)
+		router.With(app.requireAccessToken).Post("/v1/posts/{id}/publish", app.publishPost)
+		router.With(app.requireAccessToken).Put("/v1/posts/{id}/schedule", app.schedulePost)
+		router.With(app.requireAccessToken).Delete("/v1/posts/{id}/schedule", app.unschedulePost)
+		router.With(app.requireAccessToken).Delete("/v1/posts/{id}", app.deletePost)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}/revisions", app.getPostRevisions)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}/revisions/diff", app.diffPostRevisions)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}/revisions/{revision}", app.getPostRevision)
+		router.With(app.requireAccessToken).Post("/v1/posts/{id}/revisions/{revision}/restore", app.restorePostRevision)
+		router.With(app.requireAccessToken).Put("/v1/posts/{id}/tags", app.setPostTags)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}/tags", app.getPostTags)
+		router.With(app.requireAccessToken).Post("/v1/posts/{id}/comments", app.createComment)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}/comments", app.getPostComments)
+		router.With(app.requireAccessToken).Patch("/v1/comments/{id}", app.updateComment)
+		router.With(app.requireAccessToken).Put("/v1/comments/{id}/state", app.moderateComment)
+		router.With(app.requireAccessToken).Delete("/v1/comments/{id}", app.deleteComment)
+		router.With(app.requireAccessToken).Get("/v1/posts/{id}/reactions", app.getPostReactions)
+		router.With(app.requireAccessToken).Put("/v1/posts/{id}/reactions/{emoji}", app.reactToPost)
+		router.With(app.requireAccessToken).Delete("/v1/posts/{id}/reactions/{emoji}", app.unreactToPost)
+		router.With(app.requireAccessToken).Get("/v1/comments/{id}/reactions", app.getCommentReactions)
+		router.With(app.requireAccessToken).Put("/v1/comments/{id}/reactions/{emoji}", app.reactToComment)
+		router.With(app.requireAccessToken).Delete("/v1/comments/{id}/reactions/{emoji}", app.unreactToComment)
+		router.With(app.requireAccessToken).Put("/v1/posts/{id}/progress", app.setReadingProgress)
+		router.With(app.requireAccessToken).Get("/v1/reading", app.getContinueReading)
+		router.With(app.requireAccessToken).Post("/v1/bookmarks", app.addBookmark)
+		router.With(app.requireAccessToken).Get("/v1/bookmarks", app.getBookmarks)
+		router.With(app.requireAccessToken).Put("/v1/bookmarks", app.reorderBookmarks)
+		router.With(app.requireAccessToken).Delete("/v1/bookmarks/{post}", app.removeBookmark)
+		router.With(app.requireAccessToken).Post("/v1/series", app.createSeries)
+		router.With(app.requireAccessToken).Get("/v1/series/{id}", app.getSeries)
+		router.With(app.requireAccessToken).Patch("/v1/series/{id}", app.updateSeries)
+		router.With(app.requireAccessToken).Delete("/v1/series/{id}", app.deleteSeries)
+		router.With(app.requireAccessToken).Post("/v1/series/{id}/posts", app.addSeriesPost)
+		router.With(app.requireAccessToken).Put("/v1/series/{id}/posts", app.reorderSeriesPosts)
+		router.With(app.requireAccessToken).Delete("/v1/series/{id}/posts/{post}", app.removeSeriesPost)
+		router.With(app.requireAccessToken).Put("/v1/users/{username}/follow", app.followUser)
+		router.With(app.requireAccessToken).Delete("/v1/users/{username}/follow", app.unfollowUser)
+		router.With(app.requireAccessToken).Get("/v1/feed", app.getFeed)
+		router.Get("/v1/users/{username}", app.getPublicProfile)
+		router.Get("/v1/users/{username}/followers", app.getFollowers)
+		router.Post("/v1/users/{username}/subscribers", app.subscribe)
+		router.Post("/v1/users/{username}/subscribers/confirm", app.confirmSubscription)
+		router.Get("/v1/email/unsubscribe", app.confirmUnsubscribeEmail)
+		router.Post("/v1/email/unsubscribe", app.unsubscribeEmail)
+		router.Post("/v1/email/resubscribe", app.resubscribeEmail)
+		router.Get("/v1/users/{username}/following", app.getFollowing)
+		router.Get("/v1/users/{username}/posts/{slug}", app.getPostBySlug)
+		router.Get("/v1/tags", app.getPopularTags)
+		router.Get("/v1/tags/{tag}/posts", app.getTagPosts)
+		router.Get("/v1/search", app.searchPosts)
+		router.Get("/feeds/{format}", app.getSiteFeed)
+		router.Get("/feeds/users/{username}/{format}", app.getUserFeed)
+		router.Get("/feeds/tags/{tag}/{format}", app.getTagFeed)
+
+		if _, ok := app.store.(storage.Presigner); ok {
+			router.With(app.requireAccessToken).Post("/v1/uploads", app.createUpload)
+		}
+	})
+
+	router.Group(func(router chi.Router) {
+		router.Use(middleware.RequestSize(int64(app.config.UploadSize)))
+		router.With(app.requireAccessToken).Post("/v1/users/avatar", app.uploadAvatar)
+		router.With(app.requireAccessToken).Post("/v1/posts/{id}/images", app.uploadPostImage)
+	})
+
+	router.Get("/media/{id}/{file}", app.getMedia)
 	return router
 }
 
@@ -26,5 +99,10 @@ func (app *application) jobs() asynq.Handler {
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(typeOTPEmail, app.handleOTPEmailDelivery)
 	mux.HandleFunc(typeLoginEmail, app.handleLoginEmailTask)
+	mux.HandleFunc(typeTokenReuseEmail, app.handleTokenReuseEmailTask)
+	mux.HandleFunc(typePublishPost, app.handlePublishPostTask)
+	mux.HandleFunc(typeFlushReactions, app.handleFlushReactionsTask)
+	mux.HandleFunc(typeNewsletterFanout, app.handleNewsletterFanoutTask)
+	mux.HandleFunc(typeNewsletterBatch, app.handleNewsletterBatchTask)
 	return mux
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/micahasowata/blog/internal/models"
+)
+
+const typePublishPost = "post:publish"
+
+type publishPostPayload struct {
+	ID        string
+	PublishAt time.Time
+}
+
+// publishPostTaskID includes the publish time, so rescheduling never collides
+// with a task for an earlier time that is already running. Such a task finds
+// the new publish time in the post and does nothing.
+func publishPostTaskID(id string, publishAt time.Time) string {
+	return typePublishPost + ":" + id + ":" + strconv.FormatInt(publishAt.UnixNano(), 10)
+}
+
+func (app *application) newPublishPostTask(payload publishPostPayload) (*asynq.Task, error) {
+	p, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(typePublishPost, p, asynq.MaxRetry(5), asynq.TaskID(publishPostTaskID(payload.ID, payload.PublishAt))), nil
+}
+
+// cancelPublishPostTask removes the pending publish task for the post
+// scheduled at publishAt so it can be replaced or dropped. A task that is
+// missing or already running is left alone.
+func (app *application) cancelPublishPostTask(id string, publishAt *time.Time) error {
+	if publishAt == nil {
+		return nil
+	}
+
+	taskID := publishPostTaskID(id, *publishAt)
+
+	err := app.inspector.DeleteTask("default", taskID)
+	if err == nil || errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+		return nil
+	}
+
+	info, infoErr := app.inspector.GetTaskInfo("default", taskID)
+	if infoErr == nil && info.State == asynq.TaskStateActive {
+		return nil
+	}
+
+	return err
+}
+
+// schedulePublishPostTask replaces the task for the previous publish time, if
+// any, with one for payload.PublishAt.
+func (app *application) schedulePublishPostTask(ctx context.Context, previous *time.Time, payload publishPostPayload) error {
+	err := app.cancelPublishPostTask(payload.ID, previous)
+	if err != nil {
+		return err
+	}
+
+	task, err := app.newPublishPostTask(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.executor.EnqueueContext(ctx, task, asynq.ProcessAt(payload.PublishAt))
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+
+	return nil
+}
+
+func (app *application) handlePublishPostTask(ctx context.Context, t *asynq.Task) error {
+	payload := publishPostPayload{}
+
+	err := jsoniter.Unmarshal(t.Payload(), &payload)
+	if err != nil {
+		return err
+	}
+
+	post, err := app.models.Posts.GetByID(payload.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if post.Published || post.PublishAt == nil || !post.PublishAt.Equal(payload.PublishAt) {
+		return nil
+	}
+
+	_, err = app.models.Posts.Publish(post.ID)
+	if err != nil {
+		return err
+	}
+
+	return app.enqueueNewsletter(ctx, post.ID)
+}
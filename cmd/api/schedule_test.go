@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPublishPostTask(t *testing.T) {
+	app := setupApp(t, nil)
+
+	payload := publishPostPayload{
+		ID:        xid.New().String(),
+		PublishAt: time.Now().Add(time.Hour),
+	}
+
+	task, err := app.newPublishPostTask(payload)
+	require.Nil(t, err)
+	require.NotNil(t, task)
+
+	assert.Equal(t, typePublishPost, task.Type())
+}
+
+func TestHandlePublishPostTask(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	at := time.Now().Add(time.Hour)
+
+	post, err := app.models.Posts.Schedule(post.ID, &at)
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t.Run("stale", func(t *testing.T) {
+		task, err := app.newPublishPostTask(publishPostPayload{
+			ID:        post.ID,
+			PublishAt: post.PublishAt.Add(-time.Minute),
+		})
+		require.Nil(t, err)
+
+		err = app.handlePublishPostTask(ctx, task)
+		require.Nil(t, err)
+
+		p, err := app.models.Posts.GetByID(post.ID)
+		require.Nil(t, err)
+		assert.False(t, p.Published)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		task, err := app.newPublishPostTask(publishPostPayload{
+			ID:        post.ID,
+			PublishAt: *post.PublishAt,
+		})
+		require.Nil(t, err)
+
+		err = app.handlePublishPostTask(ctx, task)
+		require.Nil(t, err)
+
+		p, err := app.models.Posts.GetByID(post.ID)
+		require.Nil(t, err)
+		assert.True(t, p.Published)
+
+		err = app.handlePublishPostTask(ctx, task)
+		require.Nil(t, err)
+	})
+
+	t.Run("missing post", func(t *testing.T) {
+		task, err := app.newPublishPostTask(publishPostPayload{
+			ID:        xid.New().String(),
+			PublishAt: at,
+		})
+		require.Nil(t, err)
+
+		err = app.handlePublishPostTask(ctx, task)
+		require.Nil(t, err)
+	})
+}
+
+func TestSchedulePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name  string
+		body  string
+		token string
+		code  int
+	}{
+		{
+			name:  "valid",
+			body:  fmt.Sprintf(`{"publish_at": "%s"}`, future),
+			token: accessToken,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "reschedule",
+			body:  fmt.Sprintf(`{"publish_at": "%s"}`, future),
+			token: accessToken,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "past",
+			body:  fmt.Sprintf(`{"publish_at": "%s"}`, past),
+			token: accessToken,
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "not author",
+			body:  fmt.Sprintf(`{"publish_at": "%s"}`, future),
+			token: secondToken,
+			code:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.PUT("/v1/posts/"+post.ID+"/schedule").
+				WithHeader(jason.ContentType, jason.ContentTypeJSON).
+				WithHeader("Authorization", "Bearer "+tt.token).
+				WithBytes([]byte(tt.body)).
+				Expect().
+				Status(tt.code)
+		})
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.DELETE("/v1/posts/"+post.ID+"/schedule").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("post").Object().Value("publish_at").IsNull()
+	})
+}
+
+func TestPublishPostTaskID(t *testing.T) {
+	id := xid.New().String()
+	at := time.Now().Add(time.Hour)
+
+	assert.Equal(t, publishPostTaskID(id, at), publishPostTaskID(id, at))
+	assert.NotEqual(t, publishPostTaskID(id, at), publishPostTaskID(id, at.Add(time.Minute)))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+func (app *application) searchPosts(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var input struct {
+		Q      string `json:"q" validate:"required,lte=200"`
+		Author string `json:"author" validate:"lte=50"`
+		Tag    string `json:"tag" validate:"lte=50"`
+	}
+
+	input.Q = qs.Get("q")
+	input.Author = qs.Get("author")
+	input.Tag = qs.Get("tag")
+
+	p, err := app.readPage(qs)
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	filter := &models.SearchFilter{
+		Query: input.Q,
+		Tag:   input.Tag,
+	}
+
+	if input.Author != "" {
+		user, err := app.models.Users.GetByUsername(input.Author)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrUserNotFound):
+				app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+			default:
+				app.serverErrorHandler(w, err)
+			}
+			return
+		}
+
+		filter.Author = user.ID
+	}
+
+	results, err := app.models.Search.Search(filter, p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	results, metadata := paginate(p, results, searchPosition)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"results": results, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchPosts(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	_, err := app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		query map[string]string
+		code  int
+		count int
+	}{
+		{
+			name:  "match",
+			query: map[string]string{"q": "first post"},
+			code:  http.StatusOK,
+			count: 1,
+		},
+		{
+			name:  "author",
+			query: map[string]string{"q": "first", "author": "iamaddam"},
+			code:  http.StatusOK,
+			count: 1,
+		},
+		{
+			name:  "tag",
+			query: map[string]string{"q": "first", "tag": "go"},
+			code:  http.StatusOK,
+			count: 0,
+		},
+		{
+			name:  "no match",
+			query: map[string]string{"q": "kubernetes"},
+			code:  http.StatusOK,
+			count: 0,
+		},
+		{
+			name:  "missing query",
+			query: map[string]string{},
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "invalid cursor",
+			query: map[string]string{"q": "first", "cursor": "%%%"},
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "unknown author",
+			query: map[string]string{"q": "first", "author": "nobody"},
+			code:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			call := req.GET("/v1/search")
+			for key, value := range tt.query {
+				call = call.WithQuery(key, value)
+			}
+
+			res := call.Expect().Status(tt.code)
+
+			if tt.code == http.StatusOK {
+				res.JSON().Object().Value("results").Array().Length().IsEqual(tt.count)
+			}
+		})
+	}
+}
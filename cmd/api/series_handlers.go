@@ -0,0 +1,387 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+)
+
+// attachSeries adds the series position and prev/next links of a post, if it
+// belongs to a series, to the post. Every post of a series shares its author,
+// so the links are permalinks under username.
+func (app *application) attachSeries(post *models.Posts, username, viewer string) error {
+	part, err := app.models.Series.GetPart(post.ID, post.Author == viewer)
+	if err != nil {
+		if errors.Is(err, models.ErrSeriesNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	for _, link := range []*models.SeriesLink{part.Prev, part.Next} {
+		if link != nil {
+			link.Link = fmt.Sprintf("/v1/users/%s/posts/%s", url.PathEscape(username), url.PathEscape(link.Slug))
+		}
+	}
+
+	post.Series = part
+
+	return nil
+}
+
+// visibleSeries hides draft posts from everyone but the series author and
+// renumbers the remaining posts.
+func (app *application) visibleSeries(series *models.Series, viewer string) *models.Series {
+	if series.Author == viewer {
+		return series
+	}
+
+	entries := []*models.SeriesEntry{}
+
+	for _, entry := range series.Posts {
+		if !entry.Published {
+			continue
+		}
+
+		entry.Position = len(entries) + 1
+		entries = append(entries, entry)
+	}
+
+	series.Posts = entries
+
+	return series
+}
+
+func (app *application) createSeries(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Title string `json:"title" validate:"required,lte=250"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	series := &models.Series{
+		ID:     xid.New().String(),
+		Author: id,
+		Title:  input.Title,
+	}
+
+	series, err = app.models.Series.Insert(series)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusCreated, jason.Envelope{"series": series}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getSeries(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	series, err := app.models.Series.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"series": app.visibleSeries(series, id)}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) updateSeries(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Title string `json:"title" validate:"required,lte=250"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	series, err := app.models.Series.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if series.Author != id {
+		app.notPermittedHandler(w, errors.New("series can only be updated by its author"))
+		return
+	}
+
+	series.Title = input.Title
+
+	series, err = app.models.Series.Update(series)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"series": series}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) deleteSeries(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	series, err := app.models.Series.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if series.Author != id {
+		app.notPermittedHandler(w, errors.New("series can only be deleted by its author"))
+		return
+	}
+
+	err = app.models.Series.Delete(series.ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"series": "series deleted successfully"}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) addSeriesPost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Post string `json:"post" validate:"required"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	series, err := app.models.Series.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if series.Author != id {
+		app.notPermittedHandler(w, errors.New("series can only be changed by its author"))
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(input.Post)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("only the author's own posts can be added to a series"))
+		return
+	}
+
+	err = app.models.Series.AddPost(series.ID, post.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostInSeries):
+			app.conflictHandler(w, err)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	series, err = app.models.Series.GetByID(series.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"series": series}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) reorderSeriesPosts(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Posts []string `json:"posts" validate:"required,dive,required"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	series, err := app.models.Series.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if series.Author != id {
+		app.notPermittedHandler(w, errors.New("series can only be changed by its author"))
+		return
+	}
+
+	err = app.models.Series.Reorder(series.ID, input.Posts)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidSeriesOrder):
+			app.conflictHandler(w, err)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	series, err = app.models.Series.GetByID(series.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"series": series}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) removeSeriesPost(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	series, err := app.models.Series.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSeriesNotFound):
+			app.resourceNotFoundHandler(w, models.ErrSeriesNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if series.Author != id {
+		app.notPermittedHandler(w, errors.New("series can only be changed by its author"))
+		return
+	}
+
+	err = app.models.Series.RemovePost(series.ID, chi.URLParam(r, "post"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	series, err = app.models.Series.GetByID(series.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"series": series}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesHandlers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	first := setupPost(t, app, author)
+	second := setupPost(t, app, author)
+
+	for _, post := range []*models.Posts{first, second} {
+		_, err := app.models.Posts.Publish(post.ID)
+		require.Nil(t, err)
+	}
+
+	series, err := app.models.Series.Insert(&models.Series{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Learning Go",
+	})
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	t.Run("create", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.POST("/v1/series").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(`{"title": "Another series"}`)).
+			Expect().
+			Status(http.StatusCreated)
+
+		req.POST("/v1/series").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(`{"title": ""}`)).
+			Expect().
+			Status(http.StatusUnprocessableEntity)
+	})
+
+	t.Run("add posts", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		for _, post := range []*models.Posts{first, second} {
+			req.POST("/v1/series/"+series.ID+"/posts").
+				WithHeader(jason.ContentType, jason.ContentTypeJSON).
+				WithHeader("Authorization", "Bearer "+accessToken).
+				WithBytes([]byte(fmt.Sprintf(`{"post": "%s"}`, post.ID))).
+				Expect().
+				Status(http.StatusOK)
+		}
+
+		req.POST("/v1/series/"+series.ID+"/posts").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(fmt.Sprintf(`{"post": "%s"}`, first.ID))).
+			Expect().
+			Status(http.StatusConflict)
+
+		req.POST("/v1/series/"+series.ID+"/posts").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+secondToken).
+			WithBytes([]byte(fmt.Sprintf(`{"post": "%s"}`, first.ID))).
+			Expect().
+			Status(http.StatusForbidden)
+	})
+
+	t.Run("post includes series", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		part := req.GET("/v1/posts/"+second.ID).
+			WithHeader("Authorization", "Bearer "+secondToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("post").Object().Value("series").Object()
+
+		part.Value("part").IsEqual(2)
+		part.Value("total").IsEqual(2)
+		part.Value("prev").Object().Value("id").IsEqual(first.ID)
+		part.Value("prev").Object().Value("link").IsEqual("/v1/users/" + author.Username + "/posts/" + first.Slug)
+		part.Value("next").IsNull()
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.PUT("/v1/series/"+series.ID+"/posts").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(fmt.Sprintf(`{"posts": ["%s", "%s"]}`, second.ID, first.ID))).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("series").Object().Value("posts").Array().Value(0).Object().Value("id").IsEqual(second.ID)
+
+		req.PUT("/v1/series/"+series.ID+"/posts").
+			WithHeader(jason.ContentType, jason.ContentTypeJSON).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			WithBytes([]byte(fmt.Sprintf(`{"posts": ["%s"]}`, second.ID))).
+			Expect().
+			Status(http.StatusConflict)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.DELETE("/v1/series/"+series.ID+"/posts/"+second.ID).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("series").Object().Value("posts").Array().Value(0).Object().Value("position").IsEqual(1)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.DELETE("/v1/series/"+series.ID).
+			WithHeader("Authorization", "Bearer "+secondToken).
+			Expect().
+			Status(http.StatusForbidden)
+
+		req.DELETE("/v1/series/"+series.ID).
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK)
+	})
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -33,6 +35,27 @@ func (app *application) serve() {
 		app.logger.Fatal("asynq server error", zap.Error(err))
 	}
 
+	scheduler := asynq.NewScheduler(rdc, &asynq.SchedulerOpts{
+		Logger: app.logger.Sugar(),
+	})
+
+	_, err = scheduler.Register("@every 1m", app.newFlushReactionsTask())
+	if err != nil {
+		app.logger.Fatal("asynq scheduler error", zap.Error(err))
+	}
+
+	err = scheduler.Start()
+	if err != nil {
+		app.logger.Fatal("asynq scheduler error", zap.Error(err))
+	}
+
+	// Stop scheduling before the processor drains, so no new work lands on
+	// a processor that is going away.
+	shutdown := sync.OnceFunc(func() {
+		scheduler.Shutdown()
+		processor.Shutdown()
+	})
+
 	manager := finish.New()
 	manager.Log = app.logger.Sugar()
 
@@ -42,10 +65,16 @@ func (app *application) serve() {
 	go func() {
 		err := server.ListenAndServe()
 		if err != http.ErrServerClosed {
-			processor.Shutdown()
+			shutdown()
 			app.logger.Error(err.Error())
 		}
 	}()
 
 	manager.Wait()
+
+	shutdown()
+
+	if closer, ok := app.mailer.(io.Closer); ok {
+		closer.Close()
+	}
 }
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"testing"
 	"time"
 
@@ -11,10 +10,11 @@ import (
 	en_translations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/kataras/jwt"
 	"github.com/micahasowata/blog/internal/config"
 	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/mailer"
 	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/blog/internal/storage"
 	"github.com/micahasowata/jason"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
@@ -39,6 +39,11 @@ func setupApp(t *testing.T, db *pgxpool.Pool) *application {
 	cfg, err := config.New()
 	require.Nil(t, err)
 
+	// Every test request comes from the same IP, so the real limits would
+	// trip across tests.
+	unlimited := config.RateLimit{Requests: 100000, Period: time.Minute}
+	cfg.AuthLimit, cfg.WriteLimit, cfg.ReadLimit = unlimited, unlimited, unlimited
+
 	localeEN := en.New()
 	universal := ut.New(localeEN, localeEN)
 
@@ -52,14 +57,18 @@ func setupApp(t *testing.T, db *pgxpool.Pool) *application {
 		Addr: cfg.RDB,
 	})
 
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{
+		Addr: cfg.RDB,
+	})
+
 	rclient := redis.NewClient(&redis.Options{
 		Addr: cfg.RDB,
 	})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	blocklist := newTokenBlocklist(rclient)
 
-	blocklist := jwt.NewBlocklistContext(ctx, 1*time.Hour)
+	store, err := storage.NewLocal(t.TempDir())
+	require.Nil(t, err)
 
 	app := &application{
 		Jason:      jason.New(int64(cfg.MaxSize), false, true),
@@ -69,8 +78,11 @@ func setupApp(t *testing.T, db *pgxpool.Pool) *application {
 		translator: translator,
 		models:     models.New(db),
 		executor:   executor,
+		inspector:  inspector,
 		rclient:    rclient,
 		blocklist:  blocklist,
+		store:      store,
+		mailer:     mailer.NewMemory(),
 	}
 
 	return app
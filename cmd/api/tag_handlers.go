@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/jason"
+)
+
+func (app *application) setPostTags(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Tags []string `json:"tags" validate:"lte=10,dive,required,lte=50"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("post can only be tagged by its author"))
+		return
+	}
+
+	tags, err := app.models.Tags.SetForPost(post.ID, input.Tags)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"tags": tags}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getPostTags(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if !post.Published && post.Author != id {
+		app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		return
+	}
+
+	tags, err := app.models.Tags.GetAllByPost(post.ID)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"tags": tags}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getTagPosts(w http.ResponseWriter, r *http.Request) {
+	p, err := app.readPage(r.URL.Query())
+	if err != nil {
+		app.pageErrHandler(w, err)
+		return
+	}
+
+	posts, err := app.models.Tags.GetPosts(chi.URLParam(r, "tag"), p.Cursor, p.Limit+1)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	posts, metadata := paginate(p, posts, postPosition)
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"posts": posts, "metadata": metadata}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getPopularTags(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Limit int `validate:"gte=1,lte=100"`
+	}
+
+	var err error
+
+	input.Limit, err = app.readInt(r.URL.Query(), "limit", 20)
+	if err != nil {
+		app.badRequestHandler(w, err)
+		return
+	}
+
+	err = app.validate.Struct(&input)
+	if err != nil {
+		app.validationErrHandler(w, err)
+		return
+	}
+
+	tags, err := app.models.Tags.GetPopular(input.Limit)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"tags": tags}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
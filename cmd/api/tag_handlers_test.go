@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/jason"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPostTags(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		body  string
+		token string
+		code  int
+	}{
+		{
+			name:  "valid",
+			body:  `{"tags": ["Go", "postgres"]}`,
+			token: accessToken,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "bad body",
+			body:  `{"tags": "go"}`,
+			token: accessToken,
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "invalid body",
+			body:  `{"tags": [""]}`,
+			token: accessToken,
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "not author",
+			body:  `{"tags": ["go"]}`,
+			token: secondToken,
+			code:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.PUT("/v1/posts/"+post.ID+"/tags").
+				WithHeader(jason.ContentType, jason.ContentTypeJSON).
+				WithHeader("Authorization", "Bearer "+tt.token).
+				WithBytes([]byte(tt.body)).
+				Expect().
+				Status(tt.code)
+		})
+	}
+
+	t.Run("list", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/v1/posts/"+post.ID+"/tags").
+			WithHeader("Authorization", "Bearer "+accessToken).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("tags").Array().Length().IsEqual(2)
+	})
+}
+
+func TestGetTagPosts(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, _ := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	_, err := app.models.Tags.SetForPost(post.ID, []string{"go"})
+	require.Nil(t, err)
+
+	_, err = app.models.Posts.Publish(post.ID)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{
+			name:  "valid",
+			query: "?limit=10",
+			code:  http.StatusOK,
+		},
+		{
+			name:  "defaults",
+			query: "",
+			code:  http.StatusOK,
+		},
+		{
+			name:  "bad cursor",
+			query: "?cursor=one",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "invalid limit",
+			query: "?limit=500",
+			code:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.GET("/v1/tags/Go/posts" + tt.query).
+				Expect().
+				Status(tt.code)
+		})
+	}
+
+	t.Run("popular", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/v1/tags").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("tags").Array().Length().IsEqual(1)
+	})
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/micahasowata/blog/internal/images"
+	"github.com/micahasowata/blog/internal/models"
+	"github.com/micahasowata/blog/internal/storage"
+	"github.com/micahasowata/jason"
+	"github.com/rs/xid"
+)
+
+// Uploaded files are never overwritten, so they can be cached for good.
+const mediaCacheControl = "public, max-age=31536000, immutable"
+
+// presignExpiry is how long presigned links to the media store stay valid.
+const presignExpiry = 15 * time.Minute
+
+var errMissingFile = &jason.Err{Code: http.StatusBadRequest, Msg: "request must include an image in the file field or an upload id"}
+
+type mediaVariant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type media struct {
+	ID       string                   `json:"id"`
+	Variants map[string]*mediaVariant `json:"variants"`
+}
+
+// readUpload returns the bytes of the image sent in the multipart file
+// field. Clients of a presigning store may instead PUT the image to the
+// store first and send its upload id as JSON.
+func (app *application) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if _, ok := app.store.(storage.Presigner); !ok {
+			return nil, http.ErrMissingFile
+		}
+
+		return app.readStagedUpload(w, r)
+	}
+
+	err := r.ParseMultipartForm(int64(app.config.UploadSize))
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// stagedUploadKey is where a user's direct upload waits to be processed.
+func stagedUploadKey(user, id string) string {
+	return "uploads/" + user + "/" + id
+}
+
+func (app *application) readStagedUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	id := r.Context().Value(userID).(string)
+
+	var input struct {
+		Upload string `json:"upload"`
+	}
+
+	err := app.Read(w, r, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only ids handed out by createUpload are accepted, so the key cannot
+	// be steered outside the user's own uploads.
+	_, err = xid.FromString(input.Upload)
+	if err != nil {
+		return nil, http.ErrMissingFile
+	}
+
+	key := stagedUploadKey(id, input.Upload)
+
+	object, err := app.store.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, http.ErrMissingFile
+		}
+		return nil, err
+	}
+
+	defer object.Body.Close()
+	defer app.deleteMedia(r.Context(), key)
+
+	if object.Size > int64(app.config.UploadSize) {
+		return nil, &http.MaxBytesError{Limit: int64(app.config.UploadSize)}
+	}
+
+	return io.ReadAll(io.LimitReader(object.Body, int64(app.config.UploadSize)))
+}
+
+func (app *application) uploadErrHandler(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		app.requestTooLargeHandler(w, err)
+	case errors.Is(err, http.ErrMissingFile):
+		app.badRequestHandler(w, errMissingFile)
+	case errors.As(err, new(*jason.Err)):
+		app.badRequestHandler(w, err)
+	case errors.Is(err, images.ErrUnsupportedType), errors.Is(err, images.ErrTooLarge):
+		app.unsupportedMediaHandler(w, err)
+	default:
+		app.serverErrorHandler(w, err)
+	}
+}
+
+// storeImage resizes an upload into its variants and saves every one of
+// them under a fresh media id.
+func (app *application) storeImage(r *http.Request, data []byte) (*media, error) {
+	variants, err := images.Process(data, images.Thumbnail, images.Display)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &media{
+		ID:       xid.New().String(),
+		Variants: map[string]*mediaVariant{},
+	}
+
+	keys := []string{}
+
+	for name, img := range variants {
+		key := "media/" + m.ID + "/" + name + img.Ext
+
+		err = app.store.Put(r.Context(), key, img.ContentType, bytes.NewReader(img.Data))
+		if err != nil {
+			app.deleteMedia(r.Context(), keys...)
+			return nil, err
+		}
+
+		keys = append(keys, key)
+
+		m.Variants[name] = &mediaVariant{
+			URL:    app.config.BaseURL + "/" + key,
+			Width:  img.Width,
+			Height: img.Height,
+		}
+	}
+
+	return m, nil
+}
+
+// deleteMedia removes stored files. Failures are only logged since an
+// orphaned file does no harm.
+func (app *application) deleteMedia(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		err := app.store.Delete(ctx, key)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	}
+}
+
+// mediaKeys returns the storage keys of every variant of an image this
+// server stored, given the URL of one of them.
+func mediaKeys(link string) []string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "media" {
+		return nil
+	}
+
+	dot := strings.LastIndex(parts[2], ".")
+	if dot < 0 {
+		return nil
+	}
+
+	ext := parts[2][dot:]
+
+	return []string{
+		"media/" + parts[1] + "/" + images.Thumbnail.Name + ext,
+		"media/" + parts[1] + "/" + images.Display.Name + ext,
+	}
+}
+
+func (app *application) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	data, err := app.readUpload(w, r)
+	if err != nil {
+		app.uploadErrHandler(w, err)
+		return
+	}
+
+	user, err := app.models.Users.GetByID(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	m, err := app.storeImage(r, data)
+	if err != nil {
+		app.uploadErrHandler(w, err)
+		return
+	}
+
+	previous := user.Avatar
+	user.Avatar = m.Variants[images.Display.Name].URL
+
+	user, err = app.models.Users.Update(user)
+	if err != nil {
+		app.deleteMedia(r.Context(), mediaKeys(m.Variants[images.Display.Name].URL)...)
+
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if strings.HasPrefix(previous, app.config.BaseURL+"/media/") {
+		app.deleteMedia(r.Context(), mediaKeys(previous)...)
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"user": user, "avatar": m}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) uploadPostImage(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	post, err := app.models.Posts.GetByID(chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			app.resourceNotFoundHandler(w, models.ErrPostNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	if post.Author != id {
+		app.notPermittedHandler(w, errors.New("images can only be added to a post by its author"))
+		return
+	}
+
+	data, err := app.readUpload(w, r)
+	if err != nil {
+		app.uploadErrHandler(w, err)
+		return
+	}
+
+	m, err := app.storeImage(r, data)
+	if err != nil {
+		app.uploadErrHandler(w, err)
+		return
+	}
+
+	err = app.Write(w, http.StatusCreated, jason.Envelope{"image": m}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
+func (app *application) getMedia(w http.ResponseWriter, r *http.Request) {
+	key := "media/" + chi.URLParam(r, "id") + "/" + chi.URLParam(r, "file")
+
+	if presigner, ok := app.store.(storage.Presigner); ok {
+		link, err := presigner.PresignGet(r.Context(), key, presignExpiry)
+		if err != nil {
+			app.serverErrorHandler(w, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(presignExpiry.Seconds())/2))
+		http.Redirect(w, r, link, http.StatusFound)
+		return
+	}
+
+	object, err := app.store.Get(r.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			app.notFoundHandler(w, r)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	defer object.Body.Close()
+
+	w.Header().Set("Content-Type", object.ContentType)
+	w.Header().Set("Cache-Control", mediaCacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, "", object.Modified, object.Body)
+}
+
+// createUpload hands out a presigned link the client can PUT an image to.
+// The returned id is then sent to the avatar or post image endpoint.
+func (app *application) createUpload(w http.ResponseWriter, r *http.Request) {
+	id := r.Context().Value(userID).(string)
+
+	presigner := app.store.(storage.Presigner)
+
+	upload := xid.New().String()
+
+	link, err := presigner.PresignPut(r.Context(), stagedUploadKey(id, upload), presignExpiry)
+	if err != nil {
+		app.serverErrorHandler(w, err)
+		return
+	}
+
+	payload := jason.Envelope{
+		"id":      upload,
+		"url":     link,
+		"method":  http.MethodPut,
+		"expires": time.Now().Add(presignExpiry).UTC(),
+	}
+
+	err = app.Write(w, http.StatusCreated, jason.Envelope{"upload": payload}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/micahasowata/blog/internal/storage"
+	"github.com/micahasowata/jason"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.Nil(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+func TestMediaKeys(t *testing.T) {
+	keys := mediaKeys("https://example.com/media/cnp3vq0p2c7m5k0q4gkg/display.jpg")
+	assert.Equal(t, []string{
+		"media/cnp3vq0p2c7m5k0q4gkg/thumbnail.jpg",
+		"media/cnp3vq0p2c7m5k0q4gkg/display.jpg",
+	}, keys)
+
+	assert.Nil(t, mediaKeys("https://example.com/avatars/me.png"))
+	assert.Nil(t, mediaKeys("https://example.com/media/cnp3vq0p2c7m5k0q4gkg/display"))
+}
+
+func TestUploadAvatar(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	_, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	app.config.BaseURL = server.URL
+
+	req := httpexpect.Default(t, server.URL)
+
+	body := req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithMultipart().
+		WithFile("file", "avatar.gif", bytes.NewReader(testPNG(t, 800, 400))).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	avatar := body.Value("user").Object().Value("avatar").String().Raw()
+	require.True(t, strings.HasPrefix(avatar, server.URL+"/media/"))
+
+	variants := body.Value("avatar").Object().Value("variants").Object()
+	variants.Value("thumbnail").Object().Value("width").Number().IsEqual(320)
+	variants.Value("display").Object().Value("width").Number().IsEqual(800)
+
+	resp := req.GET(strings.TrimPrefix(avatar, server.URL)).
+		Expect().
+		Status(http.StatusOK)
+
+	resp.Header("Content-Type").IsEqual("image/png")
+	resp.Header("Cache-Control").IsEqual(mediaCacheControl)
+
+	req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithMultipart().
+		WithFile("file", "avatar.png", strings.NewReader("<svg></svg>")).
+		Expect().
+		Status(http.StatusUnsupportedMediaType)
+
+	req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithMultipart().
+		WithFormField("name", "avatar").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithMultipart().
+		WithFile("file", "avatar.png", bytes.NewReader(make([]byte, app.config.UploadSize+1))).
+		Expect().
+		Status(http.StatusRequestEntityTooLarge)
+
+	req.GET("/media/nothing/display.png").
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func TestUploadPostImage(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	author, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, secondToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	post := setupPost(t, app, author)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		post  string
+		token string
+		code  int
+	}{
+		{
+			name:  "valid",
+			post:  post.ID,
+			token: accessToken,
+			code:  http.StatusCreated,
+		},
+		{
+			name:  "not author",
+			post:  post.ID,
+			token: secondToken,
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "missing post",
+			post:  "nothing",
+			token: accessToken,
+			code:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httpexpect.Default(t, server.URL)
+
+			req.POST("/v1/posts/"+tt.post+"/images").
+				WithHeader("Authorization", "Bearer "+tt.token).
+				WithMultipart().
+				WithFile("file", "image.png", bytes.NewReader(testPNG(t, 64, 64))).
+				Expect().
+				Status(tt.code)
+		})
+	}
+}
+
+func setupS3Store(t *testing.T) *storage.S3 {
+	t.Helper()
+
+	backend := s3mem.New()
+	require.Nil(t, backend.CreateBucket("media"))
+
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	store, err := storage.NewS3(&storage.S3Options{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+
+	require.Nil(t, err)
+
+	return store
+}
+
+func TestDirectUpload(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+	app.store = setupS3Store(t)
+
+	_, accessToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	app.config.BaseURL = server.URL
+
+	req := httpexpect.Default(t, server.URL)
+
+	upload := req.POST("/v1/uploads").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		Expect().
+		Status(http.StatusCreated).
+		JSON().Object().Value("upload").Object()
+
+	put, err := http.NewRequest(http.MethodPut, upload.Value("url").String().Raw(), bytes.NewReader(testPNG(t, 64, 64)))
+	require.Nil(t, err)
+
+	resp, err := http.DefaultClient.Do(put)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	avatar := req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"upload": upload.Value("id").String().Raw()}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("user").Object().Value("avatar").String().Raw()
+
+	req.GET(strings.TrimPrefix(avatar, server.URL)).
+		WithRedirectPolicy(httpexpect.DontFollowRedirects).
+		Expect().
+		Status(http.StatusFound).
+		Header("Location").NotEmpty()
+
+	req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"upload": upload.Value("id").String().Raw()}).
+		Expect().
+		Status(http.StatusBadRequest)
+}
+
+func TestStagedUploadTraversal(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	_, victimToken := setupAuthor(t, app, "iamaddam", "addam@gmail.com")
+	_, accessToken := setupAuthor(t, app, "iamaddam42", "mayoraddam@gmail.com")
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	app.config.BaseURL = server.URL
+
+	req := httpexpect.Default(t, server.URL)
+
+	avatar := req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+victimToken).
+		WithMultipart().
+		WithFile("file", "avatar.png", bytes.NewReader(testPNG(t, 64, 64))).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("user").Object().Value("avatar").String().Raw()
+
+	path := strings.TrimPrefix(avatar, server.URL)
+	upload := "../.." + path
+
+	// The local store cannot presign, so there is no staged upload to take.
+	req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"upload": upload}).
+		Expect().
+		Status(http.StatusBadRequest)
+
+	req.GET(path).
+		Expect().
+		Status(http.StatusOK)
+
+	local := app.store
+	app.store = setupS3Store(t)
+
+	object, err := local.Get(context.Background(), strings.TrimPrefix(path, "/"))
+	require.Nil(t, err)
+
+	data, err := io.ReadAll(object.Body)
+	require.Nil(t, err)
+	require.Nil(t, object.Body.Close())
+
+	err = app.store.Put(context.Background(), strings.TrimPrefix(path, "/"), "image/jpeg", bytes.NewReader(data))
+	require.Nil(t, err)
+
+	req.POST("/v1/users/avatar").
+		WithHeader("Authorization", "Bearer "+accessToken).
+		WithHeader(jason.ContentType, jason.ContentTypeJSON).
+		WithJSON(map[string]string{"upload": upload}).
+		Expect().
+		Status(http.StatusBadRequest)
+
+	_, err = app.store.Get(context.Background(), strings.TrimPrefix(path, "/"))
+	require.Nil(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/micahasowata/blog/internal/models"
 	"github.com/micahasowata/jason"
 	"github.com/rs/xid"
@@ -37,9 +38,7 @@ func (app *application) registerUser(w http.ResponseWriter, r *http.Request) {
 		Email:    input.Email,
 	}
 
-	token := app.newToken()
-
-	err = app.rclient.Set(r.Context(), token, user.Email, 5*time.Hour).Err()
+	token, err := app.issueOTP(r.Context(), otpVerifyEmail, user.Email)
 	if err != nil {
 		app.serverErrorHandler(w, err)
 		return
@@ -94,13 +93,56 @@ func (app *application) getUserProfile(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// publicProfile is the part of a user that anyone can see.
+type publicProfile struct {
+	ID       string    `json:"id"`
+	Created  time.Time `json:"created"`
+	Name     string    `json:"name"`
+	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+	Bio      string    `json:"bio"`
+	Links    []string  `json:"links"`
+}
+
+func (app *application) getPublicProfile(w http.ResponseWriter, r *http.Request) {
+	user, err := app.models.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			app.resourceNotFoundHandler(w, models.ErrUserNotFound)
+		default:
+			app.serverErrorHandler(w, err)
+		}
+		return
+	}
+
+	profile := &publicProfile{
+		ID:       user.ID,
+		Created:  user.Created,
+		Name:     user.Name,
+		Username: user.Username,
+		Avatar:   user.Avatar,
+		Bio:      user.Bio,
+		Links:    user.Links,
+	}
+
+	err = app.Write(w, http.StatusOK, jason.Envelope{"user": profile}, nil)
+	if err != nil {
+		app.writeErrHandler(w, err)
+		return
+	}
+}
+
 func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.Context().Value(userID).(string)
 
 	var input struct {
-		Name     *string `json:"name" validate:"omitempty,lte=150"`
-		Username *string `json:"username" validate:"omitempty,gte=2,lte=25,ascii"`
-		Email    *string `json:"email" validate:"omitempty,email,lte=150"`
+		Name     *string   `json:"name" validate:"omitempty,lte=150"`
+		Username *string   `json:"username" validate:"omitempty,gte=2,lte=25,ascii"`
+		Email    *string   `json:"email" validate:"omitempty,email,lte=150"`
+		Avatar   *string   `json:"avatar" validate:"omitempty,http_url,lte=500"`
+		Bio      *string   `json:"bio" validate:"omitempty,lte=300"`
+		Links    *[]string `json:"links" validate:"omitempty,lte=5,dive,required,http_url,lte=200"`
 	}
 
 	err := app.Read(w, r, &input)
@@ -138,6 +180,18 @@ func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request
 		user.Email = *input.Email
 	}
 
+	if input.Avatar != nil {
+		user.Avatar = *input.Avatar
+	}
+
+	if input.Bio != nil {
+		user.Bio = *input.Bio
+	}
+
+	if input.Links != nil {
+		user.Links = *input.Links
+	}
+
 	user, err = app.models.Users.Update(user)
 	if err != nil {
 		switch {
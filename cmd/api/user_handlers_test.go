@@ -3,6 +3,7 @@ package main
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gavv/httpexpect/v2"
@@ -170,6 +171,24 @@ func TestUpdateUser(t *testing.T) {
 			token: secondToken,
 			code:  http.StatusConflict,
 		},
+		{
+			name:  "profile",
+			body:  `{"avatar": "https://example.com/addam.png", "bio": "I write about Go", "links": ["https://github.com/iamaddam"]}`,
+			token: accessToken,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "invalid link",
+			body:  `{"links": ["javascript:alert(1)"]}`,
+			token: accessToken,
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "long bio",
+			body:  `{"bio": "` + strings.Repeat("a", 301) + `"}`,
+			token: accessToken,
+			code:  http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, tt := range tests {
@@ -186,6 +205,50 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestGetPublicProfile(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	app := setupApp(t, tdb)
+
+	user := &models.Users{
+		ID:       xid.New().String(),
+		Name:     "addam",
+		Username: "iamaddam",
+		Email:    "addam@gmail.com",
+		Bio:      "I write about Go",
+		Links:    []string{"https://github.com/iamaddam"},
+	}
+
+	_, err := app.models.Users.Insert(user)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(app.routes())
+	defer server.Close()
+
+	t.Run("valid", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		profile := req.GET("/v1/users/iamaddam").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("user").Object()
+
+		profile.Value("bio").IsEqual("I write about Go")
+		profile.Value("links").Array().IsEqual([]string{"https://github.com/iamaddam"})
+		profile.NotContainsKey("email")
+		profile.NotContainsKey("verified")
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		req := httpexpect.Default(t, server.URL)
+
+		req.GET("/v1/users/nobody").
+			Expect().
+			Status(http.StatusNotFound)
+	})
+}
+
 func TestDeleteUserProfile(t *testing.T) {
 	tdb := setupDB(t)
 	defer db.Clean(tdb)
@@ -2,23 +2,49 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// RateLimit allows Requests per Period, spread evenly over the period.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
 type Config struct {
-	Address      string
-	MaxSize      int
-	ProdDSN      string
-	TestDSN      string
-	RDB          string
-	From         string
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUsername string
-	SMTPPassword string
-	Key          []byte
-	IPKey        string
+	Address        string
+	BaseURL        string
+	MaxSize        int
+	UploadSize     int
+	Storage        string
+	StorageDir     string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UseSSL       bool
+	ProdDSN        string
+	TestDSN        string
+	RDB            string
+	From           string
+	Mailer         string
+	MailDir        string
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	Key            []byte
+	IPKey          string
+	TrustedProxies []netip.Prefix
+	AuthLimit      RateLimit
+	WriteLimit     RateLimit
+	ReadLimit      RateLimit
 }
 
 func New() (*Config, error) {
@@ -27,29 +53,146 @@ func New() (*Config, error) {
 		return nil, err
 	}
 
+	uploadSize, err := parseSize("UPLOAD_MAX_SIZE", os.Getenv("UPLOAD_MAX_SIZE"), 10<<20)
+	if err != nil {
+		return nil, err
+	}
+
 	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
 	if err != nil {
 		return nil, err
 	}
 
+	authLimit, err := parseRateLimit(os.Getenv("RATE_LIMIT_AUTH"), RateLimit{Requests: 10, Period: time.Minute})
+	if err != nil {
+		return nil, err
+	}
+
+	writeLimit, err := parseRateLimit(os.Getenv("RATE_LIMIT_WRITE"), RateLimit{Requests: 60, Period: time.Minute})
+	if err != nil {
+		return nil, err
+	}
+
+	readLimit, err := parseRateLimit(os.Getenv("RATE_LIMIT_READ"), RateLimit{Requests: 300, Period: time.Minute})
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Uploads stay in one place on disk however the binary is started.
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "uploads"
+	}
+
 	key := os.Getenv("KEY")
 	if len(key) != 32 {
 		return nil, errors.New("token key is invalid len" + string(rune(len(key))))
 	}
 
 	cfg := &Config{
-		Address:      os.Getenv("ADDR"),
-		MaxSize:      size,
-		ProdDSN:      os.Getenv("PROD_DSN"),
-		TestDSN:      os.Getenv("TEST_DSN"),
-		RDB:          os.Getenv("RDB"),
-		From:         os.Getenv("FROM"),
-		SMTPHost:     os.Getenv("SMTP_HOST"),
-		SMTPPort:     port,
-		SMTPUsername: os.Getenv("SMTP_USERNAME"),
-		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
-		Key:          []byte(key),
-		IPKey:        os.Getenv("IP_KEY"),
+		Address:        os.Getenv("ADDR"),
+		BaseURL:        strings.TrimSuffix(os.Getenv("BASE_URL"), "/"),
+		MaxSize:        size,
+		UploadSize:     uploadSize,
+		Storage:        os.Getenv("STORAGE"),
+		StorageDir:     storageDir,
+		S3Endpoint:     os.Getenv("S3_ENDPOINT"),
+		S3Region:       os.Getenv("S3_REGION"),
+		S3Bucket:       os.Getenv("S3_BUCKET"),
+		S3AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:    os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:       os.Getenv("S3_USE_SSL") == "true",
+		ProdDSN:        os.Getenv("PROD_DSN"),
+		TestDSN:        os.Getenv("TEST_DSN"),
+		RDB:            os.Getenv("RDB"),
+		From:           os.Getenv("FROM"),
+		Mailer:         os.Getenv("MAILER"),
+		MailDir:        os.Getenv("MAIL_DIR"),
+		SMTPHost:       os.Getenv("SMTP_HOST"),
+		SMTPPort:       port,
+		SMTPUsername:   os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:   os.Getenv("SMTP_PASSWORD"),
+		Key:            []byte(key),
+		IPKey:          os.Getenv("IP_KEY"),
+		TrustedProxies: trustedProxies,
+		AuthLimit:      authLimit,
+		WriteLimit:     writeLimit,
+		ReadLimit:      readLimit,
 	}
 	return cfg, nil
 }
+
+// parseSize reads a size in bytes from the setting called name.
+func parseSize(name, value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	return n, nil
+}
+
+// parseRateLimit reads limits written as requests/period, such as 10/1m.
+func parseRateLimit(value string, fallback RateLimit) (RateLimit, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+// parseTrustedProxies reads a comma separated list of addresses and CIDR
+// ranges, such as 10.0.0.0/8,192.168.1.10.
+func parseTrustedProxies(value string) ([]netip.Prefix, error) {
+	proxies := []netip.Prefix{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return proxies, nil
+}
@@ -33,14 +33,21 @@ func NewTest(cfg *config.Config) (*pgxpool.Pool, error) {
 }
 
 func Clean(db *pgxpool.Pool) error {
-	query := `DELETE FROM users`
+	queries := []string{
+		`DELETE FROM users`,
+		`DELETE FROM tags`,
+		`DELETE FROM reactions`,
+		`DELETE FROM suppressions`,
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := db.Exec(ctx, query)
-	if err != nil {
-		return err
+	for _, query := range queries {
+		_, err := db.Exec(ctx, query)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
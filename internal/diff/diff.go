@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+var ops = map[diffmatchpatch.Operation]string{
+	diffmatchpatch.DiffEqual:  OpEqual,
+	diffmatchpatch.DiffInsert: OpInsert,
+	diffmatchpatch.DiffDelete: OpDelete,
+}
+
+// Lines returns a line by line diff that turns from into to.
+func Lines(from, to string) []Line {
+	dmp := diffmatchpatch.New()
+
+	a, b, lines := dmp.DiffLinesToChars(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	result := []Line{}
+
+	for _, d := range diffs {
+		text := strings.TrimSuffix(d.Text, "\n")
+
+		for _, line := range strings.Split(text, "\n") {
+			result = append(result, Line{
+				Op:   ops[d.Type],
+				Text: line,
+			})
+		}
+	}
+
+	return result
+}
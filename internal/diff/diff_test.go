@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		lines []Line
+	}{
+		{
+			name: "equal",
+			from: "a\nb",
+			to:   "a\nb",
+			lines: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpEqual, Text: "b"},
+			},
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc\n",
+			to:   "a\nB\nc\n",
+			lines: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpDelete, Text: "b"},
+				{Op: OpInsert, Text: "B"},
+				{Op: OpEqual, Text: "c"},
+			},
+		},
+		{
+			name: "appended lines",
+			from: "a\n",
+			to:   "a\nb\nc\n",
+			lines: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpInsert, Text: "b"},
+				{Op: OpInsert, Text: "c"},
+			},
+		},
+		{
+			name:  "empty",
+			from:  "",
+			to:    "",
+			lines: []Line{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.lines, Lines(tt.from, tt.to))
+		})
+	}
+}
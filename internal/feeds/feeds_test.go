@@ -0,0 +1,113 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	return &Feed{
+		Title:       "addam",
+		Link:        "https://example.com/v1/users/iamaddam",
+		FeedLink:    "https://example.com/feeds/users/iamaddam/rss",
+		Description: "Posts by addam",
+		Updated:     published.Add(time.Hour),
+		Items: []*Item{
+			{
+				ID:        "cnp3vq0p2c7m5k0q4gkg",
+				Title:     "Hello & welcome",
+				Link:      "https://example.com/v1/users/iamaddam/posts/hello-welcome",
+				Author:    "iamaddam",
+				Published: published,
+				Updated:   published.Add(time.Hour),
+				Content:   "<p>My <strong>very</strong> first post</p>",
+			},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	body, err := RSS(testFeed())
+	require.Nil(t, err)
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title   string `xml:"title"`
+				GUID    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+				Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	err = xml.Unmarshal(body, &doc)
+	require.Nil(t, err)
+
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "addam", doc.Channel.Title)
+	require.Len(t, doc.Channel.Items, 1)
+	assert.Equal(t, "Hello & welcome", doc.Channel.Items[0].Title)
+	assert.Equal(t, "Fri, 01 Mar 2024 10:00:00 +0000", doc.Channel.Items[0].PubDate)
+	assert.Equal(t, "<p>My <strong>very</strong> first post</p>", doc.Channel.Items[0].Content)
+}
+
+func TestAtom(t *testing.T) {
+	body, err := Atom(testFeed())
+	require.Nil(t, err)
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Author  string `xml:"author>name"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+
+	err = xml.Unmarshal(body, &doc)
+	require.Nil(t, err)
+
+	assert.Equal(t, "2024-03-01T11:00:00Z", doc.Updated)
+	require.Len(t, doc.Entries, 1)
+	assert.Equal(t, "iamaddam", doc.Entries[0].Author)
+	assert.Equal(t, "html", doc.Entries[0].Content.Type)
+	assert.Equal(t, "<p>My <strong>very</strong> first post</p>", doc.Entries[0].Content.Value)
+}
+
+func TestJSON(t *testing.T) {
+	body, err := JSON(testFeed())
+	require.Nil(t, err)
+
+	var doc struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ID          string `json:"id"`
+			ContentHTML string `json:"content_html"`
+			Authors     []struct {
+				Name string `json:"name"`
+			} `json:"authors"`
+		} `json:"items"`
+	}
+
+	err = json.Unmarshal(body, &doc)
+	require.Nil(t, err)
+
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc.Version)
+	require.Len(t, doc.Items, 1)
+	assert.Equal(t, "cnp3vq0p2c7m5k0q4gkg", doc.Items[0].ID)
+	assert.Equal(t, "iamaddam", doc.Items[0].Authors[0].Name)
+}
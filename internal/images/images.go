@@ -0,0 +1,144 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+// maxPixels caps the decoded size of an upload so a small, highly compressed
+// file cannot exhaust memory.
+const maxPixels = 50_000_000
+
+// Variant is a resized copy of an upload that fits inside a Size by Size
+// box. Images smaller than the box are never enlarged.
+type Variant struct {
+	Name string
+	Size int
+}
+
+var (
+	Thumbnail = Variant{Name: "thumbnail", Size: 320}
+	Display   = Variant{Name: "display", Size: 1280}
+)
+
+type Image struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Sniff returns the content type of data judged by its leading bytes. Only
+// JPEG, PNG and GIF are accepted.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return contentType, nil
+	default:
+		return "", ErrUnsupportedType
+	}
+}
+
+// Process decodes an upload and re-encodes it once per variant. Re-encoding
+// drops EXIF and any other metadata, so the JPEG orientation tag is applied
+// to the pixels first. GIFs are flattened to their first frame and stored as
+// PNG.
+func Process(data []byte, variants ...Variant) (map[string]*Image, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var src image.Image
+
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+		if err == nil {
+			src = orient(src, orientation(data))
+		}
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	images := make(map[string]*Image, len(variants))
+
+	for _, variant := range variants {
+		img := resize(src, variant.Size)
+
+		var buf bytes.Buffer
+		out := &Image{
+			Width:  img.Bounds().Dx(),
+			Height: img.Bounds().Dy(),
+		}
+
+		if contentType == "image/jpeg" {
+			out.ContentType, out.Ext = "image/jpeg", ".jpg"
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		} else {
+			out.ContentType, out.Ext = "image/png", ".png"
+			err = png.Encode(&buf, img)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.Data = buf.Bytes()
+		images[variant.Name] = out
+	}
+
+	return images, nil
+}
+
+func resize(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= size && height <= size {
+		return src
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	return dst
+}
@@ -0,0 +1,126 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withOrientation splices an APP1 segment carrying only the orientation tag
+// into a JPEG right after its SOI marker.
+func withOrientation(t *testing.T, img image.Image, value uint16) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.Nil(t, jpeg.Encode(&buf, img, nil))
+	data := buf.Bytes()
+
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], value)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestSniff(t *testing.T) {
+	contentType, err := Sniff(encodePNG(t, testImage(4, 4)))
+	require.Nil(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	_, err = Sniff([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Sniff([]byte("GIF89a but not really"))
+	require.Nil(t, err)
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("resizes", func(t *testing.T) {
+		out, err := Process(encodePNG(t, testImage(640, 320)), Variant{"small", 100}, Variant{"large", 1000})
+		require.Nil(t, err)
+
+		assert.Equal(t, 100, out["small"].Width)
+		assert.Equal(t, 50, out["small"].Height)
+		assert.Equal(t, "image/png", out["small"].ContentType)
+		assert.Equal(t, ".png", out["small"].Ext)
+
+		assert.Equal(t, 640, out["large"].Width)
+		assert.Equal(t, 320, out["large"].Height)
+	})
+
+	t.Run("strips exif", func(t *testing.T) {
+		data := withOrientation(t, testImage(40, 20), 6)
+		assert.Equal(t, 6, orientation(data))
+
+		out, err := Process(data, Variant{"display", 100})
+		require.Nil(t, err)
+
+		assert.Equal(t, "image/jpeg", out["display"].ContentType)
+		assert.Equal(t, 20, out["display"].Width)
+		assert.Equal(t, 40, out["display"].Height)
+		assert.False(t, bytes.Contains(out["display"].Data, []byte("Exif")))
+		assert.Equal(t, 1, orientation(out["display"].Data))
+	})
+
+	t.Run("rejects other types", func(t *testing.T) {
+		_, err := Process([]byte("%PDF-1.7 not an image"), Display)
+		assert.ErrorIs(t, err, ErrUnsupportedType)
+	})
+
+	t.Run("rejects corrupt images", func(t *testing.T) {
+		data := encodePNG(t, testImage(4, 4))
+		_, err := Process(data[:40], Display)
+		assert.ErrorIs(t, err, ErrUnsupportedType)
+	})
+}
+
+func TestOrient(t *testing.T) {
+	src := testImage(3, 2)
+
+	rotated := orient(src, 6)
+	assert.Equal(t, image.Rect(0, 0, 2, 3), rotated.Bounds())
+	assert.Equal(t, src.At(0, 0), rotated.At(1, 0))
+	assert.Equal(t, src.At(0, 1), rotated.At(0, 0))
+
+	flipped := orient(src, 3)
+	assert.Equal(t, src.At(0, 0), flipped.At(2, 1))
+
+	assert.Same(t, src, orient(src, 1))
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/micahasowata/blog/internal/config"
+	"github.com/wneessen/go-mail"
+)
+
+// Mailer delivers a fully built message.
+type Mailer interface {
+	Send(ctx context.Context, message *mail.Msg) error
+}
+
+// New returns the mailer named by cfg.Mailer. SMTP is the default.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mailer {
+	case "", "smtp":
+		return NewSMTP(&SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		})
+	case "file":
+		return NewOutbox(cfg.MailDir)
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", cfg.Mailer)
+	}
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wneessen/go-mail"
+)
+
+func testMessage(t *testing.T) *mail.Msg {
+	t.Helper()
+
+	message := mail.NewMsg()
+	require.Nil(t, message.From("blog@example.com"))
+	require.Nil(t, message.To("addam@gmail.com"))
+	message.Subject("hello addam")
+	message.SetBodyString(mail.TypeTextPlain, "welcome to blog")
+
+	return message
+}
+
+func TestOutbox(t *testing.T) {
+	outbox, err := NewOutbox(t.TempDir())
+	require.Nil(t, err)
+
+	err = outbox.Send(context.Background(), testMessage(t))
+	require.Nil(t, err)
+
+	names, err := outbox.Messages()
+	require.Nil(t, err)
+	require.Len(t, names, 1)
+
+	data, err := os.ReadFile(names[0])
+	require.Nil(t, err)
+
+	assert.Contains(t, string(data), "Subject: hello addam")
+	assert.Contains(t, string(data), "welcome to blog")
+
+	tmp, err := os.ReadDir(outbox.Dir + "/tmp")
+	require.Nil(t, err)
+	assert.Empty(t, tmp)
+}
+
+func TestMemory(t *testing.T) {
+	memory := NewMemory()
+
+	err := memory.Send(context.Background(), testMessage(t))
+	require.Nil(t, err)
+
+	messages := memory.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"<addam@gmail.com>"}, messages[0].GetToString())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = memory.Send(ctx, testMessage(t))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	memory.Reset()
+	assert.Empty(t, memory.Messages())
+}
+
+func TestNewSMTP(t *testing.T) {
+	_, err := NewSMTP(&SMTPOptions{Port: 587})
+	assert.NotNil(t, err)
+
+	s, err := NewSMTP(&SMTPOptions{Host: "localhost", Port: 587})
+	require.Nil(t, err)
+	assert.Nil(t, s.Close())
+}
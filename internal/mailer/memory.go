@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wneessen/go-mail"
+)
+
+// Memory keeps sent messages in memory so tests can inspect them.
+type Memory struct {
+	mu       sync.Mutex
+	messages []*mail.Msg
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(ctx context.Context, message *mail.Msg) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+// Messages returns what was sent so far, oldest first.
+func (m *Memory) Messages() []*mail.Msg {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*mail.Msg{}, m.messages...)
+}
+
+// Reset forgets every sent message.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wneessen/go-mail"
+)
+
+// Outbox writes every message to Dir as an .eml file instead of sending it,
+// which is handy in development. Dir is laid out as a maildir, so files are
+// written under tmp and only appear in new once complete.
+type Outbox struct {
+	Dir string
+}
+
+func NewOutbox(dir string) (*Outbox, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(dir, sub), 0o755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Outbox{Dir: dir}, nil
+}
+
+func (o *Outbox) Send(ctx context.Context, message *mail.Msg) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(o.Dir, "tmp"), "*.eml")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = message.WriteTo(tmp)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(o.Dir, "new", filepath.Base(tmp.Name()))
+
+	return os.Rename(tmp.Name(), name)
+}
+
+// Messages lists the files delivered to the outbox.
+func (o *Outbox) Messages() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(o.Dir, "new"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".eml") {
+			names = append(names, filepath.Join(o.Dir, "new", entry.Name()))
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wneessen/go-mail"
+)
+
+// maxIdleConns is how many open SMTP connections are kept for reuse.
+const maxIdleConns = 4
+
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// SMTP sends through a mail server, keeping a few connections open between
+// messages instead of dialing for each one.
+type SMTP struct {
+	opts []mail.Option
+	host string
+	idle chan *mail.Client
+}
+
+func NewSMTP(opts *SMTPOptions) (*SMTP, error) {
+	if opts.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+
+	s := &SMTP{
+		host: opts.Host,
+		opts: []mail.Option{
+			mail.WithPort(opts.Port),
+			mail.WithSMTPAuth(mail.SMTPAuthPlain),
+			mail.WithUsername(opts.Username),
+			mail.WithPassword(opts.Password),
+		},
+		idle: make(chan *mail.Client, maxIdleConns),
+	}
+
+	return s, nil
+}
+
+func (s *SMTP) dial(ctx context.Context) (*mail.Client, error) {
+	client, err := mail.NewClient(s.host, s.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.DialWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Send reuses an idle connection when there is one. A connection the server
+// has since dropped fails its check before anything is sent, so the message
+// is retried once on a fresh connection.
+func (s *SMTP) Send(ctx context.Context, message *mail.Msg) error {
+	var client *mail.Client
+
+	select {
+	case client = <-s.idle:
+	default:
+	}
+
+	if client != nil {
+		err := client.Send(message)
+		if err == nil {
+			s.release(client)
+			return nil
+		}
+
+		client.Close()
+
+		var sendErr *mail.SendError
+		if !errors.As(err, &sendErr) || sendErr.Reason != mail.ErrConnCheck {
+			return err
+		}
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = client.Send(message)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	s.release(client)
+
+	return nil
+}
+
+func (s *SMTP) release(client *mail.Client) {
+	select {
+	case s.idle <- client:
+	default:
+		client.Close()
+	}
+}
+
+// Close hangs up every idle connection.
+func (s *SMTP) Close() error {
+	for {
+		select {
+		case client := <-s.idle:
+			client.Close()
+		default:
+			return nil
+		}
+	}
+}
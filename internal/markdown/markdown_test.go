@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "commonmark",
+			source:   "# Hello\n\nSome *emphasis* and a [link](https://example.com).",
+			contains: []string{`<h1 id="hello">Hello</h1>`, `<em>emphasis</em>`, `<a href="https://example.com" rel="nofollow">link</a>`},
+		},
+		{
+			name:     "table",
+			source:   "| a | b |\n| - | - |\n| 1 | 2 |",
+			contains: []string{"<table>", "<th>a</th>", "<td>2</td>"},
+		},
+		{
+			name:     "footnote",
+			source:   "Text with a note[^1].\n\n[^1]: The note.",
+			contains: []string{`class="footnote-ref"`, `class="footnotes"`, `<li id="fn:1">`},
+		},
+		{
+			name:     "fenced code",
+			source:   "```go\nfmt.Println(\"hi\")\n```",
+			contains: []string{`<code class="language-go">`},
+		},
+		{
+			name:     "task list",
+			source:   "- [x] done\n- [ ] todo",
+			contains: []string{`<input checked="" disabled="" type="checkbox">`},
+		},
+		{
+			name:     "raw html",
+			source:   "<script>alert(1)</script>\n\n<img src=x onerror=alert(1)>",
+			excludes: []string{"<script>", "onerror"},
+		},
+		{
+			name:     "javascript link",
+			source:   "[click](javascript:alert(1))",
+			excludes: []string{"javascript:"},
+		},
+		{
+			name:     "code class injection",
+			source:   "```go\" onclick=\"alert(1)\nx\n```",
+			excludes: []string{"onclick"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := Render(tt.source)
+			require.Nil(t, err)
+
+			for _, s := range tt.contains {
+				assert.Contains(t, html, s)
+			}
+
+			for _, s := range tt.excludes {
+				assert.NotContains(t, html, s)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	html := Sanitize(`<p onclick="alert(1)">hi</p><script>alert(1)</script>`)
+
+	assert.Equal(t, "<p>hi</p>", html)
+}
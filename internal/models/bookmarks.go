@@ -0,0 +1,287 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Bookmark interface {
+	Insert(string, string) (*Bookmarks, error)
+	GetByReader(string, *Cursor, int) ([]*Bookmarks, error)
+	Delete(string, string) error
+	Reorder(string, []string) error
+}
+
+type Bookmarks struct {
+	Post     string    `json:"post"`
+	Created  time.Time `json:"created"`
+	Author   string    `json:"author"`
+	Title    string    `json:"title"`
+	Slug     string    `json:"slug"`
+	Position int       `json:"position"`
+	Progress int       `json:"progress"`
+}
+
+type BookmarksModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ErrBookmarkNotFound     = errors.New("bookmark not found")
+	ErrDuplicateBookmark    = errors.New("post already bookmarked")
+	ErrInvalidBookmarkOrder = errors.New("order must contain every bookmark exactly once")
+)
+
+// Insert adds a post to the end of a reader's bookmarks.
+func (m *BookmarksModel) Insert(reader, post string) (*Bookmarks, error) {
+	query := `
+	INSERT INTO bookmarks (reader, post, position)
+	SELECT $1, $2, COALESCE(max(position), 0) + 1
+	FROM bookmarks
+	WHERE reader = $1
+	RETURNING post, created, position`
+
+	details := `
+	SELECT p.author, p.title, p.slug, COALESCE(rp.progress, 0)
+	FROM posts p
+	LEFT JOIN reading_progress rp ON rp.post = p.id AND rp.reader = $1
+	WHERE p.id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	bookmark := &Bookmarks{}
+
+	err = tx.QueryRow(ctx, query, reader, post).Scan(
+		&bookmark.Post,
+		&bookmark.Created,
+		&bookmark.Position,
+	)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch {
+			case strings.Contains(pgErr.Message, `duplicate key value violates unique constraint "bookmarks_pkey"`):
+				return nil, ErrDuplicateBookmark
+			}
+		}
+		return nil, err
+	}
+
+	err = tx.QueryRow(ctx, details, reader, post).Scan(
+		&bookmark.Author,
+		&bookmark.Title,
+		&bookmark.Slug,
+		&bookmark.Progress,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookmark, nil
+}
+
+// GetByReader returns a page of a reader's bookmarks in their chosen order
+// along with how far they got through each post. The cursor is over the
+// position.
+func (m *BookmarksModel) GetByReader(reader string, cursor *Cursor, limit int) ([]*Bookmarks, error) {
+	op, order := ascendingKeyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT b.post, b.created, p.author, p.title, p.slug, b.position, COALESCE(rp.progress, 0)
+	FROM bookmarks b
+	INNER JOIN posts p ON p.id = b.post
+	LEFT JOIN reading_progress rp ON rp.post = b.post AND rp.reader = b.reader
+	WHERE b.reader = $1
+		AND ($2::integer IS NULL OR b.position %[1]s $2::integer)
+	ORDER BY b.position %[2]s
+	LIMIT $3`, op, order)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, reader, cursorPosition(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	bookmarks := []*Bookmarks{}
+
+	for rows.Next() {
+		bookmark := &Bookmarks{}
+
+		err = rows.Scan(
+			&bookmark.Post,
+			&bookmark.Created,
+			&bookmark.Author,
+			&bookmark.Title,
+			&bookmark.Slug,
+			&bookmark.Position,
+			&bookmark.Progress,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, bookmarks)
+
+	return bookmarks, nil
+}
+
+// Delete removes a bookmark. The positions of the bookmarks after it are
+// shifted down by the bookmarks_close_gap trigger.
+func (m *BookmarksModel) Delete(reader, post string) error {
+	query := `
+	DELETE FROM bookmarks
+	WHERE reader = $1 AND post = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, query, reader, post)
+	if err != nil {
+		return err
+	}
+
+	if cmd.RowsAffected() != 1 {
+		return ErrBookmarkNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reorder sets the order of every bookmark of a reader in one transaction.
+func (m *BookmarksModel) Reorder(reader string, posts []string) error {
+	count := `
+	SELECT count(*)
+	FROM bookmarks
+	WHERE reader = $1`
+
+	query := `
+	UPDATE bookmarks
+	SET position = $1
+	WHERE reader = $2 AND post = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var total int
+
+	err = tx.QueryRow(ctx, count, reader).Scan(&total)
+	if err != nil {
+		return err
+	}
+
+	if total != len(posts) {
+		return ErrInvalidBookmarkOrder
+	}
+
+	seen := map[string]bool{}
+
+	for i, post := range posts {
+		key := strings.ToLower(post)
+		if seen[key] {
+			return ErrInvalidBookmarkOrder
+		}
+		seen[key] = true
+
+		cmd, err := tx.Exec(ctx, query, i+1, reader, post)
+		if err != nil {
+			return err
+		}
+
+		if cmd.RowsAffected() != 1 {
+			return ErrInvalidBookmarkOrder
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarks(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	reader := setupAuthor(t, &UsersModel{DB: tdb})
+
+	posts := &PostsModel{
+		DB: tdb,
+	}
+
+	model := &BookmarksModel{
+		DB: tdb,
+	}
+
+	readings := &ReadingsModel{
+		DB: tdb,
+	}
+
+	ids := []string{}
+
+	for _, title := range []string{"first", "second", "third"} {
+		post, err := posts.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: reader.ID,
+			Title:  title,
+			Body:   "body",
+		})
+		require.Nil(t, err)
+
+		_, err = posts.Publish(post.ID)
+		require.Nil(t, err)
+
+		bookmark, err := model.Insert(reader.ID, post.ID)
+		require.Nil(t, err)
+		assert.Equal(t, len(ids)+1, bookmark.Position)
+
+		ids = append(ids, post.ID)
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		bookmark, err := model.Insert(reader.ID, ids[0])
+		require.NotNil(t, err)
+		require.Nil(t, bookmark)
+
+		assert.EqualError(t, err, ErrDuplicateBookmark.Error())
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		err := model.Reorder(reader.ID, []string{ids[0], ids[1]})
+		require.NotNil(t, err)
+		assert.EqualError(t, err, ErrInvalidBookmarkOrder.Error())
+
+		err = model.Reorder(reader.ID, []string{ids[2], ids[0], ids[1]})
+		require.Nil(t, err)
+
+		bookmarks, err := model.GetByReader(reader.ID, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, bookmarks, 3)
+
+		assert.Equal(t, ids[2], bookmarks[0].Post)
+		assert.Equal(t, ids[0], bookmarks[1].Post)
+
+		cursor := &Cursor{Position: bookmarks[0].Position, ID: bookmarks[0].Post}
+
+		bookmarks, err = model.GetByReader(reader.ID, cursor, 1)
+		require.Nil(t, err)
+		require.Len(t, bookmarks, 1)
+		assert.Equal(t, ids[0], bookmarks[0].Post)
+
+		cursor = &Cursor{Position: 3, ID: ids[1], Before: true}
+
+		bookmarks, err = model.GetByReader(reader.ID, cursor, 2)
+		require.Nil(t, err)
+		require.Len(t, bookmarks, 2)
+		assert.Equal(t, ids[2], bookmarks[0].Post)
+		assert.Equal(t, ids[0], bookmarks[1].Post)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		_, err := readings.SetProgress(reader.ID, ids[0], 40)
+		require.Nil(t, err)
+
+		reading, err := readings.SetProgress(reader.ID, ids[0], 60)
+		require.Nil(t, err)
+		assert.Equal(t, 60, reading.Progress)
+
+		_, err = readings.SetProgress(reader.ID, ids[1], 100)
+		require.Nil(t, err)
+
+		inProgress, err := readings.GetInProgress(reader.ID, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, inProgress, 1)
+		assert.Equal(t, ids[0], inProgress[0].Post)
+
+		bookmarks, err := model.GetByReader(reader.ID, nil, 10)
+		require.Nil(t, err)
+		assert.Equal(t, 60, bookmarks[1].Progress)
+	})
+
+	t.Run("delete closes gap", func(t *testing.T) {
+		err := model.Delete(reader.ID, ids[2])
+		require.Nil(t, err)
+
+		err = model.Delete(reader.ID, ids[2])
+		require.NotNil(t, err)
+		assert.EqualError(t, err, ErrBookmarkNotFound.Error())
+
+		bookmarks, err := model.GetByReader(reader.ID, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, bookmarks, 2)
+
+		assert.Equal(t, 1, bookmarks[0].Position)
+		assert.Equal(t, 2, bookmarks[1].Position)
+	})
+}
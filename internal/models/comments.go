@@ -0,0 +1,455 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/micahasowata/blog/internal/markdown"
+)
+
+type Comment interface {
+	Insert(*Comments) (*Comments, error)
+	GetByID(string) (*Comments, error)
+	GetThreads(string, string, bool, *Cursor, int) ([]*Comments, error)
+	GetReplies([]string) ([]*Comments, error)
+	Update(*Comments) (*Comments, error)
+	SetState(string, string) (*Comments, error)
+	Delete(string) error
+}
+
+// Comment states. Deleted comments are kept as tombstones so that their
+// replies stay in place.
+const (
+	CommentVisible = "visible"
+	CommentPending = "pending"
+	CommentHidden  = "hidden"
+	CommentDeleted = "deleted"
+)
+
+type Comments struct {
+	ID      string      `json:"id"`
+	Created time.Time   `json:"created"`
+	Updated time.Time   `json:"updated"`
+	Post    string      `json:"post"`
+	Author  string      `json:"author"`
+	Parent  *string     `json:"parent"`
+	Body    string      `json:"body"`
+	HTML    string      `json:"html"`
+	State   string      `json:"state"`
+	Replies []*Comments `json:"replies"`
+}
+
+type CommentsModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrParentNotFound  = errors.New("parent comment not found")
+)
+
+func (m *CommentsModel) Insert(comment *Comments) (*Comments, error) {
+	parent := `
+	SELECT EXISTS (
+		SELECT 1 FROM comments WHERE id = $1 AND post = $2 AND state <> 'deleted'
+	)`
+
+	query := `
+	INSERT INTO comments (id, post, author, parent, body, html, state)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, created, updated, post, author, parent, body, html, state`
+
+	html, err := markdown.Render(comment.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.State == "" {
+		comment.State = CommentVisible
+	}
+
+	args := []any{
+		comment.ID,
+		comment.Post,
+		comment.Author,
+		comment.Parent,
+		comment.Body,
+		html,
+		comment.State,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if comment.Parent != nil {
+		var exists bool
+
+		err = tx.QueryRow(ctx, parent, *comment.Parent, comment.Post).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, ErrParentNotFound
+		}
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&comment.ID,
+		&comment.Created,
+		&comment.Updated,
+		&comment.Post,
+		&comment.Author,
+		&comment.Parent,
+		&comment.Body,
+		&comment.HTML,
+		&comment.State,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (m *CommentsModel) GetByID(id string) (*Comments, error) {
+	query := `
+	SELECT id, created, updated, post, author, parent, body, html, state
+	FROM comments
+	WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	comment := &Comments{}
+
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.Created,
+		&comment.Updated,
+		&comment.Post,
+		&comment.Author,
+		&comment.Parent,
+		&comment.Body,
+		&comment.HTML,
+		&comment.State,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrCommentNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// GetThreads returns a page of the top level comments on a post that viewer
+// may see, oldest first. Moderators see every state, everyone else sees
+// visible comments, tombstones and their own pending comments. The cursor is
+// over the creation time and comment id.
+func (m *CommentsModel) GetThreads(post, viewer string, moderator bool, cursor *Cursor, limit int) ([]*Comments, error) {
+	op, order := ascendingKeyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT id, created, updated, post, author, parent, body, html, state
+	FROM comments
+	WHERE post = $1 AND parent IS NULL
+		AND ($2::boolean OR state IN ('visible', 'deleted') OR (state = 'pending' AND author = $3::citext))
+		AND ($4::timestamptz IS NULL OR (created, id) %[1]s ($4::timestamptz, $5::citext))
+	ORDER BY created %[2]s, id %[2]s
+	LIMIT $6`, op, order)
+
+	created, id := cursorArgs(cursor)
+
+	comments, err := m.list(query, post, moderator, viewer, created, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, comments)
+
+	return comments, nil
+}
+
+// GetReplies returns every reply below the given comments regardless of
+// state, oldest first, so a reply always comes after its parent. Callers
+// decide which states the viewer may see.
+func (m *CommentsModel) GetReplies(parents []string) ([]*Comments, error) {
+	query := `
+	WITH RECURSIVE replies AS (
+		SELECT id, created, updated, post, author, parent, body, html, state
+		FROM comments
+		WHERE parent = ANY($1::text[])
+		UNION ALL
+		SELECT c.id, c.created, c.updated, c.post, c.author, c.parent, c.body, c.html, c.state
+		FROM comments c
+		INNER JOIN replies r ON c.parent = r.id
+	)
+	SELECT id, created, updated, post, author, parent, body, html, state
+	FROM replies
+	ORDER BY created, id`
+
+	return m.list(query, parents)
+}
+
+func (m *CommentsModel) list(query string, args ...any) ([]*Comments, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	comments := []*Comments{}
+
+	for rows.Next() {
+		comment := &Comments{}
+
+		err = rows.Scan(
+			&comment.ID,
+			&comment.Created,
+			&comment.Updated,
+			&comment.Post,
+			&comment.Author,
+			&comment.Parent,
+			&comment.Body,
+			&comment.HTML,
+			&comment.State,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (m *CommentsModel) Update(comment *Comments) (*Comments, error) {
+	query := `
+	UPDATE comments
+	SET body = $1, html = $2, updated = now()
+	WHERE id = $3 AND author = $4 AND state <> 'deleted'
+	RETURNING id, created, updated, post, author, parent, body, html, state`
+
+	html, err := markdown.Render(comment.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{
+		comment.Body,
+		html,
+		comment.ID,
+		comment.Author,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&comment.ID,
+		&comment.Created,
+		&comment.Updated,
+		&comment.Post,
+		&comment.Author,
+		&comment.Parent,
+		&comment.Body,
+		&comment.HTML,
+		&comment.State,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrCommentNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// SetState moves a comment between the visible, pending and hidden states.
+// Deleted comments stay deleted.
+func (m *CommentsModel) SetState(id, state string) (*Comments, error) {
+	query := `
+	UPDATE comments
+	SET state = $1, updated = now()
+	WHERE id = $2 AND state <> 'deleted'
+	RETURNING id, created, updated, post, author, parent, body, html, state`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	comment := &Comments{}
+
+	err = tx.QueryRow(ctx, query, state, id).Scan(
+		&comment.ID,
+		&comment.Created,
+		&comment.Updated,
+		&comment.Post,
+		&comment.Author,
+		&comment.Parent,
+		&comment.Body,
+		&comment.HTML,
+		&comment.State,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrCommentNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// Delete replaces a comment with a tombstone. The row is kept so replies
+// remain attached to the thread.
+func (m *CommentsModel) Delete(id string) error {
+	query := `
+	UPDATE comments
+	SET state = 'deleted', body = '', html = '', updated = now()
+	WHERE id = $1 AND state <> 'deleted'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if cmd.RowsAffected() != 1 {
+		return ErrCommentNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
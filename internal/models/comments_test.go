@@ -0,0 +1,162 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComments(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	post, err := (&PostsModel{DB: tdb}).Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	model := &CommentsModel{
+		DB: tdb,
+	}
+
+	comment, err := model.Insert(&Comments{
+		ID:     xid.New().String(),
+		Post:   post.ID,
+		Author: author.ID,
+		Body:   "**nice** <script>alert(1)</script>",
+	})
+	require.Nil(t, err)
+
+	t.Run("insert", func(t *testing.T) {
+		assert.Equal(t, CommentVisible, comment.State)
+		assert.Nil(t, comment.Parent)
+		assert.Contains(t, comment.HTML, "<strong>nice</strong>")
+		assert.NotContains(t, comment.HTML, "<script>")
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		reply, err := model.Insert(&Comments{
+			ID:     xid.New().String(),
+			Post:   post.ID,
+			Author: author.ID,
+			Parent: &comment.ID,
+			Body:   "thanks",
+		})
+		require.Nil(t, err)
+		require.NotNil(t, reply.Parent)
+
+		assert.Equal(t, comment.ID, *reply.Parent)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		parent := xid.New().String()
+
+		reply, err := model.Insert(&Comments{
+			ID:     xid.New().String(),
+			Post:   post.ID,
+			Author: author.ID,
+			Parent: &parent,
+			Body:   "thanks",
+		})
+		require.NotNil(t, err)
+		require.Nil(t, reply)
+
+		assert.EqualError(t, err, ErrParentNotFound.Error())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		comment.Body = "edited"
+
+		updated, err := model.Update(comment)
+		require.Nil(t, err)
+
+		assert.Equal(t, "<p>edited</p>\n", updated.HTML)
+	})
+
+	t.Run("set state", func(t *testing.T) {
+		hidden, err := model.SetState(comment.ID, CommentHidden)
+		require.Nil(t, err)
+
+		assert.Equal(t, CommentHidden, hidden.State)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := model.Delete(comment.ID)
+		require.Nil(t, err)
+
+		tombstone, err := model.GetByID(comment.ID)
+		require.Nil(t, err)
+
+		assert.Equal(t, CommentDeleted, tombstone.State)
+		assert.Empty(t, tombstone.Body)
+		assert.Empty(t, tombstone.HTML)
+
+		err = model.Delete(comment.ID)
+		require.NotNil(t, err)
+		assert.EqualError(t, err, ErrCommentNotFound.Error())
+	})
+
+	t.Run("threads", func(t *testing.T) {
+		roots, err := model.GetThreads(post.ID, author.ID, true, nil, 10)
+		require.Nil(t, err)
+
+		require.Len(t, roots, 1)
+		assert.Equal(t, comment.ID, roots[0].ID)
+
+		cursor := &Cursor{Key: roots[0].Created, ID: roots[0].ID}
+
+		roots, err = model.GetThreads(post.ID, author.ID, true, cursor, 10)
+		require.Nil(t, err)
+		assert.Empty(t, roots)
+
+		replies, err := model.GetReplies([]string{comment.ID})
+		require.Nil(t, err)
+
+		require.Len(t, replies, 1)
+		assert.Equal(t, comment.ID, *replies[0].Parent)
+	})
+	t.Run("reply with a lower id", func(t *testing.T) {
+		// xid only orders ids to the second, so a reply can carry an id
+		// lower than its parent's.
+		childID := xid.New().String()
+		parentID := xid.New().String()
+
+		parent, err := model.Insert(&Comments{
+			ID:     parentID,
+			Post:   post.ID,
+			Author: author.ID,
+			Parent: &comment.ID,
+			Body:   "parent",
+		})
+		require.Nil(t, err)
+
+		_, err = model.Insert(&Comments{
+			ID:     childID,
+			Post:   post.ID,
+			Author: author.ID,
+			Parent: &parent.ID,
+			Body:   "child",
+		})
+		require.Nil(t, err)
+
+		replies, err := model.GetReplies([]string{comment.ID})
+		require.Nil(t, err)
+
+		position := map[string]int{}
+		for i, reply := range replies {
+			position[reply.ID] = i
+		}
+
+		require.Contains(t, position, parentID)
+		require.Contains(t, position, childID)
+		assert.Less(t, position[parentID], position[childID])
+	})
+}
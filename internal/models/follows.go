@@ -0,0 +1,281 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Follow interface {
+	Insert(string, string) error
+	Delete(string, string) error
+	GetFollowers(string, *Cursor, int) ([]*Follows, error)
+	GetFollowing(string, *Cursor, int) ([]*Follows, error)
+	GetFeed(string, *Cursor, int) ([]*Posts, error)
+}
+
+// Follows is the public view of a user on the other side of a follow.
+type Follows struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Username string    `json:"username"`
+	Followed time.Time `json:"followed"`
+}
+
+type FollowsModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert makes follower follow followee. Following someone twice is a
+// no-op.
+func (m *FollowsModel) Insert(follower, followee string) error {
+	query := `
+	INSERT INTO follows (follower, followee)
+	VALUES ($1, $2)
+	ON CONFLICT (follower, followee) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, follower, followee)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *FollowsModel) Delete(follower, followee string) error {
+	query := `
+	DELETE FROM follows
+	WHERE follower = $1 AND followee = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, follower, followee)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetFollowers lists the users following user, most recent first. The
+// cursor is over the follow time and the follower's id.
+func (m *FollowsModel) GetFollowers(user string, cursor *Cursor, limit int) ([]*Follows, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT u.id, u.name, u.username, f.created
+	FROM follows f
+	INNER JOIN users u ON u.id = f.follower
+	WHERE f.followee = $1
+		AND ($2::timestamptz IS NULL OR (f.created, u.id) %[1]s ($2::timestamptz, $3::citext))
+	ORDER BY f.created %[2]s, u.id %[2]s
+	LIMIT $4`, op, order)
+
+	return m.list(query, user, cursor, limit)
+}
+
+// GetFollowing lists the users user follows, most recent first. The cursor
+// is over the follow time and the followee's id.
+func (m *FollowsModel) GetFollowing(user string, cursor *Cursor, limit int) ([]*Follows, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT u.id, u.name, u.username, f.created
+	FROM follows f
+	INNER JOIN users u ON u.id = f.followee
+	WHERE f.follower = $1
+		AND ($2::timestamptz IS NULL OR (f.created, u.id) %[1]s ($2::timestamptz, $3::citext))
+	ORDER BY f.created %[2]s, u.id %[2]s
+	LIMIT $4`, op, order)
+
+	return m.list(query, user, cursor, limit)
+}
+
+func (m *FollowsModel) list(query, user string, cursor *Cursor, limit int) ([]*Follows, error) {
+	key, id := cursorArgs(cursor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, user, key, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	follows := []*Follows{}
+
+	for rows.Next() {
+		follow := &Follows{}
+
+		err = rows.Scan(
+			&follow.ID,
+			&follow.Name,
+			&follow.Username,
+			&follow.Followed,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		follows = append(follows, follow)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, follows)
+
+	return follows, nil
+}
+
+// GetFeed returns the newest published posts of the authors a user follows,
+// paged by a cursor over the publish time and post id. Each followed author
+// contributes at most limit posts through the lateral join, so the query
+// only touches the head of every author's index no matter how many authors
+// are followed.
+func (m *FollowsModel) GetFeed(follower string, cursor *Cursor, limit int) ([]*Posts, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT p.id, p.created, p.updated, p.author, p.title, p.slug, p.body, p.html, p.published, p.published_at, p.publish_at
+	FROM follows f
+	CROSS JOIN LATERAL (
+		SELECT *
+		FROM posts
+		WHERE author = f.followee
+			AND published = true
+			AND ($2::timestamptz IS NULL OR (published_at, id) %[1]s ($2::timestamptz, $3::citext))
+		ORDER BY published_at %[2]s, id %[2]s
+		LIMIT $4
+	) p
+	WHERE f.follower = $1
+	ORDER BY p.published_at %[2]s, p.id %[2]s
+	LIMIT $4`, op, order)
+
+	publishedAt, id := cursorArgs(cursor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, follower, publishedAt, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []*Posts{}
+
+	for rows.Next() {
+		post := &Posts{}
+
+		err = rows.Scan(
+			&post.ID,
+			&post.Created,
+			&post.Updated,
+			&post.Author,
+			&post.Title,
+			&post.Slug,
+			&post.Body,
+			&post.HTML,
+			&post.Published,
+			&post.PublishedAt,
+			&post.PublishAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, posts)
+
+	return posts, nil
+}
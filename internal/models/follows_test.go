@@ -0,0 +1,119 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollows(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	users := &UsersModel{DB: tdb}
+
+	author := setupAuthor(t, users)
+
+	reader, err := users.Insert(&Users{
+		ID:       xid.New().String(),
+		Name:     "Eve",
+		Username: "iameve",
+		Email:    "eve@gmail.com",
+	})
+	require.Nil(t, err)
+
+	posts := &PostsModel{
+		DB: tdb,
+	}
+
+	model := &FollowsModel{
+		DB: tdb,
+	}
+
+	ids := []string{}
+
+	for _, title := range []string{"first", "second", "third"} {
+		post, err := posts.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: author.ID,
+			Title:  title,
+			Body:   "body",
+		})
+		require.Nil(t, err)
+
+		_, err = posts.Publish(post.ID)
+		require.Nil(t, err)
+
+		ids = append(ids, post.ID)
+	}
+
+	_, err = posts.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "draft",
+		Body:   "body",
+	})
+	require.Nil(t, err)
+
+	t.Run("empty feed", func(t *testing.T) {
+		feed, err := model.GetFeed(reader.ID, nil, 10)
+		require.Nil(t, err)
+		assert.Empty(t, feed)
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		err := model.Insert(reader.ID, author.ID)
+		require.Nil(t, err)
+
+		err = model.Insert(reader.ID, author.ID)
+		require.Nil(t, err)
+
+		followers, err := model.GetFollowers(author.ID, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, followers, 1)
+		assert.Equal(t, reader.ID, followers[0].ID)
+
+		following, err := model.GetFollowing(reader.ID, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, following, 1)
+		assert.Equal(t, author.ID, following[0].ID)
+	})
+
+	t.Run("feed", func(t *testing.T) {
+		feed, err := model.GetFeed(reader.ID, nil, 2)
+		require.Nil(t, err)
+		require.Len(t, feed, 2)
+
+		assert.Equal(t, ids[2], feed[0].ID)
+		assert.Equal(t, ids[1], feed[1].ID)
+
+		last := feed[1]
+
+		feed, err = model.GetFeed(reader.ID, &Cursor{Key: *last.PublishedAt, ID: last.ID}, 2)
+		require.Nil(t, err)
+		require.Len(t, feed, 1)
+
+		assert.Equal(t, ids[0], feed[0].ID)
+
+		first := feed[0]
+
+		feed, err = model.GetFeed(reader.ID, &Cursor{Key: *first.PublishedAt, ID: first.ID, Before: true}, 2)
+		require.Nil(t, err)
+		require.Len(t, feed, 2)
+
+		assert.Equal(t, ids[2], feed[0].ID)
+		assert.Equal(t, ids[1], feed[1].ID)
+	})
+
+	t.Run("unfollow", func(t *testing.T) {
+		err := model.Delete(reader.ID, author.ID)
+		require.Nil(t, err)
+
+		feed, err := model.GetFeed(reader.ID, nil, 10)
+		require.Nil(t, err)
+		assert.Empty(t, feed)
+	})
+}
@@ -3,7 +3,19 @@ package models
 import "github.com/jackc/pgx/v5/pgxpool"
 
 type Models struct {
-	Users User
+	Users        User
+	Posts        Post
+	Revisions    Revision
+	Tags         Tag
+	Series       PostSeries
+	Comments     Comment
+	Reactions    Reaction
+	Follows      Follow
+	Bookmarks    Bookmark
+	Readings     Reading
+	Search       Searcher
+	Subscribers  Subscriber
+	Suppressions Suppression
 }
 
 func New(db *pgxpool.Pool) *Models {
@@ -11,6 +23,42 @@ func New(db *pgxpool.Pool) *Models {
 		Users: &UsersModel{
 			DB: db,
 		},
+		Posts: &PostsModel{
+			DB: db,
+		},
+		Revisions: &RevisionsModel{
+			DB: db,
+		},
+		Tags: &TagsModel{
+			DB: db,
+		},
+		Series: &SeriesModel{
+			DB: db,
+		},
+		Comments: &CommentsModel{
+			DB: db,
+		},
+		Reactions: &ReactionsModel{
+			DB: db,
+		},
+		Follows: &FollowsModel{
+			DB: db,
+		},
+		Bookmarks: &BookmarksModel{
+			DB: db,
+		},
+		Readings: &ReadingsModel{
+			DB: db,
+		},
+		Search: &SearchModel{
+			DB: db,
+		},
+		Subscribers: &SubscribersModel{
+			DB: db,
+		},
+		Suppressions: &SuppressionsModel{
+			DB: db,
+		},
 	}
 	return models
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Cursor is a position in a list ordered newest first by a time sort key
+// and then by id, or oldest first where noted. A list returns the rows
+// after the cursor, or the rows just before it when Before is set. A nil
+// cursor starts at the head of the list.
+// Lists ordered by relevance, such as search results, sort on Rank before
+// Key. Lists in an order the user picked, such as bookmarks, sort on
+// Position alone, lowest first.
+type Cursor struct {
+	Rank     float32
+	Position int
+	Key      time.Time
+	ID       string
+	Before   bool
+}
+
+// keyset returns the row comparison operator and sort direction that walk a
+// list away from cursor. Rows before a cursor are read in ascending order so
+// the ones nearest to it come first, and must be put back in list order with
+// restoreOrder.
+func keyset(cursor *Cursor) (string, string) {
+	if cursor != nil && cursor.Before {
+		return ">", "ASC"
+	}
+
+	return "<", "DESC"
+}
+
+// ascendingKeyset is keyset for lists that run oldest or lowest first.
+func ascendingKeyset(cursor *Cursor) (string, string) {
+	if cursor != nil && cursor.Before {
+		return "<", "DESC"
+	}
+
+	return ">", "ASC"
+}
+
+// cursorPosition returns the query argument of a cursor over Position. It is
+// nil when there is no cursor.
+func cursorPosition(cursor *Cursor) *int {
+	if cursor == nil {
+		return nil
+	}
+
+	return &cursor.Position
+}
+
+// cursorArgs returns the query arguments of a cursor. The key is nil when
+// there is no cursor.
+func cursorArgs(cursor *Cursor) (*time.Time, string) {
+	if cursor == nil {
+		return nil, ""
+	}
+
+	return &cursor.Key, cursor.ID
+}
+
+func restoreOrder[T any](cursor *Cursor, rows []T) {
+	if cursor != nil && cursor.Before {
+		slices.Reverse(rows)
+	}
+}
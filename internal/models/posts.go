@@ -0,0 +1,768 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/micahasowata/blog/internal/markdown"
+)
+
+type Post interface {
+	Insert(*Posts) (*Posts, error)
+	GetByID(string) (*Posts, error)
+	GetBySlug(string, string) (*Posts, error)
+	GetByAuthor(string, *Cursor, int) ([]*Posts, error)
+	GetPublished(string, int) ([]*Posts, error)
+	Update(*Posts) (*Posts, error)
+	Publish(string) (*Posts, error)
+	Schedule(string, *time.Time) (*Posts, error)
+	Delete(string, string) error
+	RenderMissingHTML() (int, error)
+}
+
+type Posts struct {
+	ID          string      `json:"id"`
+	Created     time.Time   `json:"created"`
+	Updated     time.Time   `json:"updated"`
+	Author      string      `json:"author"`
+	Title       string      `json:"title"`
+	Slug        string      `json:"slug"`
+	Body        string      `json:"body"`
+	HTML        string      `json:"html"`
+	Published   bool        `json:"published"`
+	PublishedAt *time.Time  `json:"published_at"`
+	PublishAt   *time.Time  `json:"publish_at"`
+	Series      *SeriesPart `json:"series,omitempty"`
+}
+
+type PostsModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ErrPostNotFound = errors.New("post not found")
+)
+
+func (m *PostsModel) Insert(post *Posts) (*Posts, error) {
+	query := `
+	INSERT INTO posts (id, author, title, slug, body, html)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created, updated, author, title, slug, body, html, published, published_at, publish_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	slug, err := uniqueSlug(ctx, tx, post.Author, post.ID, slugify(post.Title))
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := markdown.Render(post.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{
+		post.ID,
+		post.Author,
+		post.Title,
+		slug,
+		post.Body,
+		html,
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&post.ID,
+		&post.Created,
+		&post.Updated,
+		&post.Author,
+		&post.Title,
+		&post.Slug,
+		&post.Body,
+		&post.HTML,
+		&post.Published,
+		&post.PublishedAt,
+		&post.PublishAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertRevision(ctx, tx, post)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (m *PostsModel) GetByID(id string) (*Posts, error) {
+	query := `
+	SELECT id, created, updated, author, title, slug, body, html, published, published_at, publish_at
+	FROM posts
+	WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	post := &Posts{}
+
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&post.ID,
+		&post.Created,
+		&post.Updated,
+		&post.Author,
+		&post.Title,
+		&post.Slug,
+		&post.Body,
+		&post.HTML,
+		&post.Published,
+		&post.PublishedAt,
+		&post.PublishAt,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrPostNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetBySlug looks up an author's post by its current slug, falling back to
+// the slug history so renamed posts can still be resolved.
+func (m *PostsModel) GetBySlug(author, slug string) (*Posts, error) {
+	query := `
+	SELECT id, created, updated, author, title, slug, body, html, published, published_at, publish_at
+	FROM (
+		SELECT p.*, 0 AS rank
+		FROM posts p
+		WHERE p.author = $1 AND p.slug = $2
+		UNION ALL
+		SELECT p.*, 1 AS rank
+		FROM post_slugs s
+		INNER JOIN posts p ON p.id = s.post
+		WHERE s.author = $1 AND s.slug = $2
+	) AS matches
+	ORDER BY rank
+	LIMIT 1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	post := &Posts{}
+
+	err = tx.QueryRow(ctx, query, author, slug).Scan(
+		&post.ID,
+		&post.Created,
+		&post.Updated,
+		&post.Author,
+		&post.Title,
+		&post.Slug,
+		&post.Body,
+		&post.HTML,
+		&post.Published,
+		&post.PublishedAt,
+		&post.PublishAt,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrPostNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetByAuthor returns a page of an author's posts, drafts included, newest
+// first. The cursor is over the creation time and post id.
+func (m *PostsModel) GetByAuthor(author string, cursor *Cursor, limit int) ([]*Posts, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT id, created, updated, author, title, slug, body, html, published, published_at, publish_at
+	FROM posts
+	WHERE author = $1
+		AND ($2::timestamptz IS NULL OR (created, id) %[1]s ($2::timestamptz, $3::citext))
+	ORDER BY created %[2]s, id %[2]s
+	LIMIT $4`, op, order)
+
+	created, id := cursorArgs(cursor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, author, created, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []*Posts{}
+
+	for rows.Next() {
+		post := &Posts{}
+
+		err = rows.Scan(
+			&post.ID,
+			&post.Created,
+			&post.Updated,
+			&post.Author,
+			&post.Title,
+			&post.Slug,
+			&post.Body,
+			&post.HTML,
+			&post.Published,
+			&post.PublishedAt,
+			&post.PublishAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, posts)
+
+	return posts, nil
+}
+
+// GetPublished returns the newest published posts, limited to one author
+// unless author is empty.
+func (m *PostsModel) GetPublished(author string, limit int) ([]*Posts, error) {
+	query := `
+	SELECT id, created, updated, author, title, slug, body, html, published, published_at, publish_at
+	FROM posts
+	WHERE published = true AND ($1::citext = '' OR author = $1::citext)
+	ORDER BY published_at DESC, id DESC
+	LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, author, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []*Posts{}
+
+	for rows.Next() {
+		post := &Posts{}
+
+		err = rows.Scan(
+			&post.ID,
+			&post.Created,
+			&post.Updated,
+			&post.Author,
+			&post.Title,
+			&post.Slug,
+			&post.Body,
+			&post.HTML,
+			&post.Published,
+			&post.PublishedAt,
+			&post.PublishAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (m *PostsModel) Update(post *Posts) (*Posts, error) {
+	current := `
+	SELECT title, slug
+	FROM posts
+	WHERE id = $1 AND author = $2
+	FOR UPDATE`
+
+	history := `
+	INSERT INTO post_slugs (author, slug, post)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (author, slug) DO NOTHING`
+
+	reclaim := `
+	DELETE FROM post_slugs
+	WHERE author = $1 AND slug = $2 AND post = $3`
+
+	query := `
+	UPDATE posts
+	SET title = $1, slug = $2, body = $3, html = $4, updated = now()
+	WHERE id = $5 AND author = $6
+	RETURNING id, created, updated, author, title, slug, body, html, published, published_at, publish_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var title, slug string
+
+	err = tx.QueryRow(ctx, current, post.ID, post.Author).Scan(&title, &slug)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrPostNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if slugify(title) != slugify(post.Title) {
+		newSlug, err := uniqueSlug(ctx, tx, post.Author, post.ID, slugify(post.Title))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, history, post.Author, slug, post.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, reclaim, post.Author, newSlug, post.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		slug = newSlug
+	}
+
+	html, err := markdown.Render(post.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{
+		post.Title,
+		slug,
+		post.Body,
+		html,
+		post.ID,
+		post.Author,
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&post.ID,
+		&post.Created,
+		&post.Updated,
+		&post.Author,
+		&post.Title,
+		&post.Slug,
+		&post.Body,
+		&post.HTML,
+		&post.Published,
+		&post.PublishedAt,
+		&post.PublishAt,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrPostNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = insertRevision(ctx, tx, post)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (m *PostsModel) Publish(id string) (*Posts, error) {
+	query := `
+	UPDATE posts
+	SET published = true, published_at = COALESCE(published_at, now()), publish_at = NULL, updated = now()
+	WHERE id = $1
+	RETURNING id, created, updated, author, title, slug, body, html, published, published_at, publish_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	post := &Posts{}
+
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&post.ID,
+		&post.Created,
+		&post.Updated,
+		&post.Author,
+		&post.Title,
+		&post.Slug,
+		&post.Body,
+		&post.HTML,
+		&post.Published,
+		&post.PublishedAt,
+		&post.PublishAt,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrPostNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// Schedule sets or, when at is nil, clears the time a draft should be
+// published at.
+func (m *PostsModel) Schedule(id string, at *time.Time) (*Posts, error) {
+	query := `
+	UPDATE posts
+	SET publish_at = $1, updated = now()
+	WHERE id = $2 AND published = false
+	RETURNING id, created, updated, author, title, slug, body, html, published, published_at, publish_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	post := &Posts{}
+
+	err = tx.QueryRow(ctx, query, at, id).Scan(
+		&post.ID,
+		&post.Created,
+		&post.Updated,
+		&post.Author,
+		&post.Title,
+		&post.Slug,
+		&post.Body,
+		&post.HTML,
+		&post.Published,
+		&post.PublishedAt,
+		&post.PublishAt,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrPostNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (m *PostsModel) Delete(id, author string) error {
+	query := `
+	DELETE FROM posts
+	WHERE id = $1 AND author = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, query, id, author)
+	if err != nil {
+		return err
+	}
+
+	if cmd.RowsAffected() != 1 {
+		return ErrPostNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// renderBatch is how many posts RenderMissingHTML renders per transaction.
+const renderBatch = 100
+
+// RenderMissingHTML renders the posts written before the html column
+// existed and reports how many it rendered. Posts are walked by id, so a
+// body that renders to nothing is not picked up again.
+func (m *PostsModel) RenderMissingHTML() (int, error) {
+	rendered := 0
+	after := ""
+
+	for {
+		n, last, err := m.renderMissingHTML(after)
+		if err != nil {
+			return rendered, err
+		}
+
+		rendered += n
+
+		if last == "" {
+			return rendered, nil
+		}
+
+		after = last
+	}
+}
+
+// renderMissingHTML renders one batch of posts after the given id. It
+// returns the id of the last post it looked at, or an empty id when there
+// were none left.
+func (m *PostsModel) renderMissingHTML(after string) (int, string, error) {
+	query := `
+	SELECT id, body
+	FROM posts
+	WHERE html = '' AND body <> '' AND id > $1
+	ORDER BY id
+	LIMIT $2`
+
+	update := `
+	UPDATE posts
+	SET html = $1
+	WHERE id = $2 AND html = ''`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, after, renderBatch)
+	if err != nil {
+		return 0, "", err
+	}
+
+	type source struct {
+		id   string
+		body string
+	}
+
+	var sources []source
+
+	for rows.Next() {
+		var src source
+
+		err = rows.Scan(&src.id, &src.body)
+		if err != nil {
+			rows.Close()
+			return 0, "", err
+		}
+
+		sources = append(sources, src)
+	}
+
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		return 0, "", err
+	}
+
+	if len(sources) == 0 {
+		return 0, "", nil
+	}
+
+	for _, src := range sources {
+		html, err := markdown.Render(src.body)
+		if err != nil {
+			return 0, "", err
+		}
+
+		_, err = tx.Exec(ctx, update, html, src.id)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return len(sources), sources[len(sources)-1].id, nil
+}
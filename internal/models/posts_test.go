@@ -0,0 +1,403 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuthor(t *testing.T, model *UsersModel) *Users {
+	t.Helper()
+
+	user := &Users{
+		ID:       xid.New().String(),
+		Name:     "Adam",
+		Username: "iamadam",
+		Email:    "adam45@gmail.com",
+	}
+
+	createdUser, err := model.Insert(user)
+	require.Nil(t, err)
+
+	return createdUser
+}
+
+func TestInsertPost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	post := &Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	}
+
+	createdPost, err := model.Insert(post)
+	require.Nil(t, err)
+	require.NotNil(t, createdPost)
+
+	assert.Equal(t, post.Title, createdPost.Title)
+	assert.Equal(t, post.Body, createdPost.Body)
+	assert.Equal(t, "<p>My very first post</p>\n", createdPost.HTML)
+	assert.False(t, createdPost.Published)
+	assert.Nil(t, createdPost.PublishedAt)
+}
+
+func TestRenderMissingHTML(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	post, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	_, err = tdb.Exec(context.Background(), `UPDATE posts SET html = '' WHERE id = $1`, post.ID)
+	require.Nil(t, err)
+
+	rendered, err := model.RenderMissingHTML()
+	require.Nil(t, err)
+	assert.Equal(t, 1, rendered)
+
+	post, err = model.GetByID(post.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "<p>My very first post</p>\n", post.HTML)
+
+	rendered, err = model.RenderMissingHTML()
+	require.Nil(t, err)
+	assert.Zero(t, rendered)
+}
+
+func TestGetPostByID(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	createdPost, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		post, err := model.GetByID(createdPost.ID)
+		require.Nil(t, err)
+		require.NotNil(t, post)
+
+		assert.Equal(t, createdPost, post)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		post, err := model.GetByID(xid.New().String())
+		require.NotNil(t, err)
+		require.Nil(t, post)
+
+		assert.EqualError(t, err, ErrPostNotFound.Error())
+	})
+}
+
+func TestGetPostsByAuthor(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	for _, title := range []string{"first", "second"} {
+		_, err := model.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: author.ID,
+			Title:  title,
+			Body:   "body",
+		})
+		require.Nil(t, err)
+	}
+
+	posts, err := model.GetByAuthor(author.ID, nil, 10)
+	require.Nil(t, err)
+
+	require.Len(t, posts, 2)
+	assert.Equal(t, "second", posts[0].Title)
+
+	cursor := &Cursor{Key: posts[0].Created, ID: posts[0].ID}
+
+	posts, err = model.GetByAuthor(author.ID, cursor, 10)
+	require.Nil(t, err)
+
+	require.Len(t, posts, 1)
+	assert.Equal(t, "first", posts[0].Title)
+}
+
+func TestUpdatePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	createdPost, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	tests := []struct {
+		name           string
+		post           *Posts
+		shouldCauseErr bool
+		err            error
+	}{
+		{
+			name: "valid",
+			post: &Posts{
+				ID:     createdPost.ID,
+				Author: author.ID,
+				Title:  "Hello again",
+				Body:   createdPost.Body,
+			},
+			shouldCauseErr: false,
+		},
+		{
+			name: "missing post",
+			post: &Posts{
+				ID:     xid.New().String(),
+				Author: author.ID,
+				Title:  "Hello again",
+				Body:   createdPost.Body,
+			},
+			shouldCauseErr: true,
+			err:            ErrPostNotFound,
+		},
+		{
+			name: "wrong author",
+			post: &Posts{
+				ID:     createdPost.ID,
+				Author: xid.New().String(),
+				Title:  "Hello again",
+				Body:   createdPost.Body,
+			},
+			shouldCauseErr: true,
+			err:            ErrPostNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := model.Update(tt.post)
+			if tt.shouldCauseErr {
+				require.NotNil(t, err)
+				assert.EqualError(t, err, tt.err.Error())
+				assert.Nil(t, p)
+			} else {
+				require.Nil(t, err)
+				require.NotNil(t, p)
+			}
+		})
+	}
+}
+
+func TestPublishPost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	createdPost, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	post, err := model.Publish(createdPost.ID)
+	require.Nil(t, err)
+	require.True(t, post.Published)
+	require.NotNil(t, post.PublishedAt)
+
+	republished, err := model.Publish(createdPost.ID)
+	require.Nil(t, err)
+
+	assert.Equal(t, post.PublishedAt, republished.PublishedAt)
+}
+
+func TestDeletePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	createdPost, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	err = model.Delete(createdPost.ID, xid.New().String())
+	require.NotNil(t, err)
+	assert.EqualError(t, err, ErrPostNotFound.Error())
+
+	err = model.Delete(createdPost.ID, author.ID)
+	require.Nil(t, err)
+
+	post, err := model.GetByID(createdPost.ID)
+	require.NotNil(t, err)
+	require.Nil(t, post)
+}
+
+func TestPostSlugs(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	first, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello World",
+		Body:   "body",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "hello-world", first.Slug)
+
+	second, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "HELLO world",
+		Body:   "body",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "hello-world-2", second.Slug)
+
+	first.Title = "Goodbye World"
+	updated, err := model.Update(first)
+	require.Nil(t, err)
+	assert.Equal(t, "goodbye-world", updated.Slug)
+
+	t.Run("current slug", func(t *testing.T) {
+		post, err := model.GetBySlug(author.ID, "goodbye-world")
+		require.Nil(t, err)
+
+		assert.Equal(t, first.ID, post.ID)
+	})
+
+	t.Run("old slug", func(t *testing.T) {
+		post, err := model.GetBySlug(author.ID, "Hello-World")
+		require.Nil(t, err)
+
+		assert.Equal(t, first.ID, post.ID)
+		assert.Equal(t, "goodbye-world", post.Slug)
+	})
+
+	t.Run("missing slug", func(t *testing.T) {
+		post, err := model.GetBySlug(author.ID, "missing")
+		require.NotNil(t, err)
+		require.Nil(t, post)
+
+		assert.EqualError(t, err, ErrPostNotFound.Error())
+	})
+
+	t.Run("reused title", func(t *testing.T) {
+		third, err := model.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: author.ID,
+			Title:  "Hello World",
+			Body:   "body",
+		})
+		require.Nil(t, err)
+
+		assert.Equal(t, "hello-world-3", third.Slug)
+	})
+}
+
+func TestSchedulePost(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	model := &PostsModel{
+		DB: tdb,
+	}
+
+	createdPost, err := model.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "My very first post",
+	})
+	require.Nil(t, err)
+
+	at := time.Now().Add(time.Hour)
+
+	post, err := model.Schedule(createdPost.ID, &at)
+	require.Nil(t, err)
+	require.NotNil(t, post.PublishAt)
+
+	post, err = model.Schedule(createdPost.ID, nil)
+	require.Nil(t, err)
+	require.Nil(t, post.PublishAt)
+
+	_, err = model.Schedule(createdPost.ID, &at)
+	require.Nil(t, err)
+
+	post, err = model.Publish(createdPost.ID)
+	require.Nil(t, err)
+	assert.Nil(t, post.PublishAt)
+
+	post, err = model.Schedule(createdPost.ID, &at)
+	require.NotNil(t, err)
+	require.Nil(t, post)
+
+	assert.EqualError(t, err, ErrPostNotFound.Error())
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Reading interface {
+	SetProgress(string, string, int) (*Readings, error)
+	GetInProgress(string, *Cursor, int) ([]*Readings, error)
+}
+
+// Readings records how far through a post a reader has scrolled, as a
+// percentage.
+type Readings struct {
+	Post     string    `json:"post"`
+	Updated  time.Time `json:"updated"`
+	Author   string    `json:"author"`
+	Title    string    `json:"title"`
+	Slug     string    `json:"slug"`
+	Progress int       `json:"progress"`
+}
+
+type ReadingsModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m *ReadingsModel) SetProgress(reader, post string, progress int) (*Readings, error) {
+	query := `
+	WITH saved AS (
+		INSERT INTO reading_progress (reader, post, progress)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (reader, post) DO UPDATE SET progress = EXCLUDED.progress, updated = now()
+		RETURNING post, updated, progress
+	)
+	SELECT s.post, s.updated, p.author, p.title, p.slug, s.progress
+	FROM saved s
+	INNER JOIN posts p ON p.id = s.post`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	reading := &Readings{}
+
+	err = tx.QueryRow(ctx, query, reader, post, progress).Scan(
+		&reading.Post,
+		&reading.Updated,
+		&reading.Author,
+		&reading.Title,
+		&reading.Slug,
+		&reading.Progress,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reading, nil
+}
+
+// GetInProgress returns a page of the posts a reader started but has not
+// finished, most recently read first. The cursor is over the time of the
+// last progress update and the post id.
+func (m *ReadingsModel) GetInProgress(reader string, cursor *Cursor, limit int) ([]*Readings, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT rp.post, rp.updated, p.author, p.title, p.slug, rp.progress
+	FROM reading_progress rp
+	INNER JOIN posts p ON p.id = rp.post
+	WHERE rp.reader = $1 AND rp.progress > 0 AND rp.progress < 100 AND p.published = true
+		AND ($2::timestamptz IS NULL OR (rp.updated, rp.post) %[1]s ($2::timestamptz, $3::citext))
+	ORDER BY rp.updated %[2]s, rp.post %[2]s
+	LIMIT $4`, op, order)
+
+	updated, post := cursorArgs(cursor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, reader, updated, post, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	readings := []*Readings{}
+
+	for rows.Next() {
+		reading := &Readings{}
+
+		err = rows.Scan(
+			&reading.Post,
+			&reading.Updated,
+			&reading.Author,
+			&reading.Title,
+			&reading.Slug,
+			&reading.Progress,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		readings = append(readings, reading)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, readings)
+
+	return readings, nil
+}
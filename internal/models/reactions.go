@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Reaction interface {
+	GetReactions(string, string) (map[string][]string, error)
+	SetReactions(string, string, map[string][]string) error
+}
+
+// Kinds of content that can be reacted to.
+const (
+	ReactionPost    = "post"
+	ReactionComment = "comment"
+)
+
+type ReactionsModel struct {
+	DB *pgxpool.Pool
+}
+
+// GetReactions returns the users who reacted to a post or comment keyed by
+// emoji.
+func (m *ReactionsModel) GetReactions(kind, target string) (map[string][]string, error) {
+	query := `
+	SELECT emoji, reactor
+	FROM reactions
+	WHERE kind = $1 AND target = $2
+	ORDER BY created`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, kind, target)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reactions := map[string][]string{}
+
+	for rows.Next() {
+		var emoji, reactor string
+
+		err = rows.Scan(&emoji, &reactor)
+		if err != nil {
+			return nil, err
+		}
+
+		reactions[emoji] = append(reactions[emoji], reactor)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reactions, nil
+}
+
+// SetReactions overwrites who reacted to a post or comment with each of the
+// given emojis. Emojis left out are not touched. Reactions by users that no
+// longer exist are skipped.
+func (m *ReactionsModel) SetReactions(kind, target string, reactions map[string][]string) error {
+	deleteQuery := `
+	DELETE FROM reactions
+	WHERE kind = $1 AND target = $2 AND emoji = $3 AND NOT (reactor = ANY($4::text[]))`
+
+	insertQuery := `
+	INSERT INTO reactions (kind, target, emoji, reactor)
+	SELECT $1::text, $2::text, $3::text, id
+	FROM users
+	WHERE id = ANY($4::text[])
+	ON CONFLICT (kind, target, emoji, reactor) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	for emoji, reactors := range reactions {
+		if reactors == nil {
+			reactors = []string{}
+		}
+
+		_, err = tx.Exec(ctx, deleteQuery, kind, target, emoji, reactors)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, insertQuery, kind, target, emoji, reactors)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactions(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	users := &UsersModel{DB: tdb}
+
+	first := setupAuthor(t, users)
+
+	second, err := users.Insert(&Users{
+		ID:       xid.New().String(),
+		Name:     "Eve",
+		Username: "iameve",
+		Email:    "eve@gmail.com",
+	})
+	require.Nil(t, err)
+
+	model := &ReactionsModel{
+		DB: tdb,
+	}
+
+	target := xid.New().String()
+
+	reactions, err := model.GetReactions(ReactionPost, target)
+	require.Nil(t, err)
+	assert.Empty(t, reactions)
+
+	err = model.SetReactions(ReactionPost, target, map[string][]string{
+		"like": {first.ID, second.ID},
+		"clap": {first.ID},
+	})
+	require.Nil(t, err)
+
+	err = model.SetReactions(ReactionPost, target, map[string][]string{
+		"like": {second.ID, xid.New().String()},
+	})
+	require.Nil(t, err)
+
+	reactions, err = model.GetReactions(ReactionPost, target)
+	require.Nil(t, err)
+
+	assert.Equal(t, map[string][]string{"like": {second.ID}, "clap": {first.ID}}, reactions)
+
+	reactions, err = model.GetReactions(ReactionComment, target)
+	require.Nil(t, err)
+	assert.Empty(t, reactions)
+}
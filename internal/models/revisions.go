@@ -0,0 +1,165 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/xid"
+)
+
+type Revision interface {
+	GetAllByPost(string) ([]*Revisions, error)
+	GetByID(string, string) (*Revisions, error)
+}
+
+type Revisions struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Post    string    `json:"post"`
+	Editor  string    `json:"editor"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+}
+
+type RevisionsModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// insertRevision records the saved state of post within the transaction
+// that saved it.
+func insertRevision(ctx context.Context, tx pgx.Tx, post *Posts) error {
+	query := `
+	INSERT INTO post_revisions (id, post, editor, title, body)
+	VALUES ($1, $2, $3, $4, $5)`
+
+	args := []any{
+		xid.New().String(),
+		post.ID,
+		post.Author,
+		post.Title,
+		post.Body,
+	}
+
+	_, err := tx.Exec(ctx, query, args...)
+	return err
+}
+
+func (m *RevisionsModel) GetAllByPost(post string) ([]*Revisions, error) {
+	query := `
+	SELECT id, created, post, editor, title, body
+	FROM post_revisions
+	WHERE post = $1
+	ORDER BY id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, post)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := []*Revisions{}
+
+	for rows.Next() {
+		revision := &Revisions{}
+
+		err = rows.Scan(
+			&revision.ID,
+			&revision.Created,
+			&revision.Post,
+			&revision.Editor,
+			&revision.Title,
+			&revision.Body,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (m *RevisionsModel) GetByID(post, id string) (*Revisions, error) {
+	query := `
+	SELECT id, created, post, editor, title, body
+	FROM post_revisions
+	WHERE post = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	revision := &Revisions{}
+
+	err = tx.QueryRow(ctx, query, post, id).Scan(
+		&revision.ID,
+		&revision.Created,
+		&revision.Post,
+		&revision.Editor,
+		&revision.Title,
+		&revision.Body,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrRevisionNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
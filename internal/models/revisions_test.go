@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRevisions(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	posts := &PostsModel{
+		DB: tdb,
+	}
+
+	model := &RevisionsModel{
+		DB: tdb,
+	}
+
+	post, err := posts.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Hello world",
+		Body:   "first draft",
+	})
+	require.Nil(t, err)
+
+	post.Body = "second draft"
+	_, err = posts.Update(post)
+	require.Nil(t, err)
+
+	revisions, err := model.GetAllByPost(post.ID)
+	require.Nil(t, err)
+	require.Len(t, revisions, 2)
+
+	assert.Equal(t, "second draft", revisions[0].Body)
+	assert.Equal(t, "first draft", revisions[1].Body)
+
+	t.Run("valid", func(t *testing.T) {
+		revision, err := model.GetByID(post.ID, revisions[1].ID)
+		require.Nil(t, err)
+
+		assert.Equal(t, revisions[1], revision)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		revision, err := model.GetByID(post.ID, xid.New().String())
+		require.NotNil(t, err)
+		require.Nil(t, revision)
+
+		assert.EqualError(t, err, ErrRevisionNotFound.Error())
+	})
+}
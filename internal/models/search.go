@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Searcher interface {
+	Search(*SearchFilter, *Cursor, int) ([]*SearchResult, error)
+}
+
+// SearchFilter narrows a full text search. Author and Tag are ignored when
+// empty.
+type SearchFilter struct {
+	Query  string
+	Author string
+	Tag    string
+}
+
+type SearchResult struct {
+	Post    *Posts  `json:"post"`
+	Rank    float32 `json:"rank"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+}
+
+type SearchModel struct {
+	DB *pgxpool.Pool
+}
+
+// ts_headline marks matches with these private use characters so the
+// surrounding text can be escaped before the marks become HTML.
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+var highlighter = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// highlight escapes a ts_headline fragment and turns its match markers into
+// mark elements.
+func highlight(fragment string) string {
+	return highlighter.Replace(html.EscapeString(fragment))
+}
+
+// Search ranks published posts against a web search style query. Titles
+// weigh more than tags, and tags more than bodies. The cursor is over the
+// rank, publish time and post id.
+func (m *SearchModel) Search(filter *SearchFilter, cursor *Cursor, limit int) ([]*SearchResult, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT p.id, p.created, p.updated, p.author, p.title, p.slug, p.body, p.html, p.published, p.published_at, p.publish_at,
+		ts_rank(p.search, q) AS rank,
+		ts_headline('english', p.title, q, $5),
+		ts_headline('english', p.body, q, $6)
+	FROM posts p, websearch_to_tsquery('english', $1) q
+	WHERE p.published = true
+		AND p.search @@ q
+		AND ($2::citext = '' OR p.author = $2::citext)
+		AND ($3::citext = '' OR EXISTS (
+			SELECT 1
+			FROM post_tags pt
+			INNER JOIN tags t ON t.id = pt.tag
+			WHERE pt.post = p.id AND t.name = $3::citext
+		))
+		AND ($7::timestamptz IS NULL OR (ts_rank(p.search, q), p.published_at, p.id) %[1]s ($9::real, $7::timestamptz, $8::citext))
+	ORDER BY rank %[2]s, p.published_at %[2]s, p.id %[2]s
+	LIMIT $4`, op, order)
+
+	publishedAt, id := cursorArgs(cursor)
+
+	var rank float32
+	if cursor != nil {
+		rank = cursor.Rank
+	}
+
+	marks := `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+
+	args := []any{
+		filter.Query,
+		filter.Author,
+		filter.Tag,
+		limit,
+		marks + ", HighlightAll=true",
+		marks + ", MaxWords=35, MinWords=15, MaxFragments=2",
+		publishedAt,
+		id,
+		rank,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := []*SearchResult{}
+
+	for rows.Next() {
+		post := &Posts{}
+		result := &SearchResult{Post: post}
+
+		err = rows.Scan(
+			&post.ID,
+			&post.Created,
+			&post.Updated,
+			&post.Author,
+			&post.Title,
+			&post.Slug,
+			&post.Body,
+			&post.HTML,
+			&post.Published,
+			&post.PublishedAt,
+			&post.PublishAt,
+			&result.Rank,
+			&result.Title,
+			&result.Snippet,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.Title = highlight(result.Title)
+		result.Snippet = highlight(result.Snippet)
+
+		results = append(results, result)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, results)
+
+	return results, nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlight(t *testing.T) {
+	fragment := "a <b>" + highlightStart + "postgres" + highlightStop + "</b> tip"
+
+	assert.Equal(t, "a &lt;b&gt;<mark>postgres</mark>&lt;/b&gt; tip", highlight(fragment))
+}
+
+func TestSearch(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	posts := &PostsModel{
+		DB: tdb,
+	}
+
+	tags := &TagsModel{
+		DB: tdb,
+	}
+
+	model := &SearchModel{
+		DB: tdb,
+	}
+
+	inputs := []struct {
+		title string
+		body  string
+		tags  []string
+	}{
+		{title: "Tuning postgres", body: "Indexes make queries fast.", tags: []string{"databases"}},
+		{title: "Weekend notes", body: "I spent the weekend reading about postgres internals.", tags: []string{"life"}},
+		{title: "Gardening", body: "Tomatoes need sun.", tags: []string{"postgres"}},
+	}
+
+	ids := []string{}
+
+	for _, input := range inputs {
+		post, err := posts.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: author.ID,
+			Title:  input.title,
+			Body:   input.body,
+		})
+		require.Nil(t, err)
+
+		_, err = posts.Publish(post.ID)
+		require.Nil(t, err)
+
+		_, err = tags.SetForPost(post.ID, input.tags)
+		require.Nil(t, err)
+
+		ids = append(ids, post.ID)
+	}
+
+	_, err := posts.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Draft about postgres",
+		Body:   "postgres postgres",
+	})
+	require.Nil(t, err)
+
+	t.Run("ranked", func(t *testing.T) {
+		results, err := model.Search(&SearchFilter{Query: "postgres"}, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, results, 3)
+
+		assert.Equal(t, ids[0], results[0].Post.ID)
+		assert.Equal(t, ids[2], results[1].Post.ID)
+		assert.Equal(t, ids[1], results[2].Post.ID)
+
+		assert.Contains(t, results[0].Title, "<mark>postgres</mark>")
+		assert.Contains(t, results[2].Snippet, "<mark>postgres</mark>")
+	})
+
+	t.Run("paged", func(t *testing.T) {
+		filter := &SearchFilter{Query: "postgres"}
+
+		first, err := model.Search(filter, nil, 2)
+		require.Nil(t, err)
+		require.Len(t, first, 2)
+
+		last := first[1]
+		cursor := &Cursor{Rank: last.Rank, Key: *last.Post.PublishedAt, ID: last.Post.ID}
+
+		rest, err := model.Search(filter, cursor, 2)
+		require.Nil(t, err)
+		require.Len(t, rest, 1)
+		assert.Equal(t, ids[1], rest[0].Post.ID)
+
+		cursor = &Cursor{Rank: rest[0].Rank, Key: *rest[0].Post.PublishedAt, ID: rest[0].Post.ID, Before: true}
+
+		back, err := model.Search(filter, cursor, 2)
+		require.Nil(t, err)
+		require.Len(t, back, 2)
+		assert.Equal(t, first[0].Post.ID, back[0].Post.ID)
+		assert.Equal(t, first[1].Post.ID, back[1].Post.ID)
+	})
+
+	t.Run("tag filter", func(t *testing.T) {
+		results, err := model.Search(&SearchFilter{Query: "postgres", Tag: "life"}, nil, 10)
+		require.Nil(t, err)
+		require.Len(t, results, 1)
+
+		assert.Equal(t, ids[1], results[0].Post.ID)
+	})
+
+	t.Run("author filter", func(t *testing.T) {
+		results, err := model.Search(&SearchFilter{Query: "postgres", Author: xid.New().String()}, nil, 10)
+		require.Nil(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("retagged", func(t *testing.T) {
+		_, err := tags.SetForPost(ids[2], []string{"garden"})
+		require.Nil(t, err)
+
+		results, err := model.Search(&SearchFilter{Query: "postgres"}, nil, 10)
+		require.Nil(t, err)
+		assert.Len(t, results, 2)
+	})
+}
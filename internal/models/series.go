@@ -0,0 +1,540 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostSeries interface {
+	Insert(*Series) (*Series, error)
+	GetByID(string) (*Series, error)
+	GetPart(string, bool) (*SeriesPart, error)
+	Update(*Series) (*Series, error)
+	Delete(string, string) error
+	AddPost(string, string) error
+	RemovePost(string, string) error
+	Reorder(string, []string) error
+}
+
+type Series struct {
+	ID      string         `json:"id"`
+	Created time.Time      `json:"created"`
+	Updated time.Time      `json:"updated"`
+	Author  string         `json:"author"`
+	Title   string         `json:"title"`
+	Posts   []*SeriesEntry `json:"posts"`
+}
+
+type SeriesEntry struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Published bool   `json:"published"`
+	Position  int    `json:"position"`
+}
+
+type SeriesLink struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+	Link  string `json:"link"`
+}
+
+// SeriesPart describes where a post sits within its series.
+type SeriesPart struct {
+	ID    string      `json:"id"`
+	Title string      `json:"title"`
+	Part  int         `json:"part"`
+	Total int         `json:"total"`
+	Prev  *SeriesLink `json:"prev"`
+	Next  *SeriesLink `json:"next"`
+}
+
+type SeriesModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ErrSeriesNotFound     = errors.New("series not found")
+	ErrPostInSeries       = errors.New("post already belongs to a series")
+	ErrInvalidSeriesOrder = errors.New("order must contain every post in the series exactly once")
+)
+
+func (m *SeriesModel) Insert(series *Series) (*Series, error) {
+	query := `
+	INSERT INTO series (id, author, title)
+	VALUES ($1, $2, $3)
+	RETURNING id, created, updated, author, title`
+
+	args := []any{
+		series.ID,
+		series.Author,
+		series.Title,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&series.ID,
+		&series.Created,
+		&series.Updated,
+		&series.Author,
+		&series.Title,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	series.Posts = []*SeriesEntry{}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (m *SeriesModel) GetByID(id string) (*Series, error) {
+	query := `
+	SELECT id, created, updated, author, title
+	FROM series
+	WHERE id = $1`
+
+	entries := `
+	SELECT p.id, p.title, p.slug, p.published, sp.position
+	FROM series_posts sp
+	INNER JOIN posts p ON p.id = sp.post
+	WHERE sp.series = $1
+	ORDER BY sp.position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	series := &Series{}
+
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&series.ID,
+		&series.Created,
+		&series.Updated,
+		&series.Author,
+		&series.Title,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrSeriesNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(ctx, entries, series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	series.Posts = []*SeriesEntry{}
+
+	for rows.Next() {
+		entry := &SeriesEntry{}
+
+		err = rows.Scan(
+			&entry.ID,
+			&entry.Title,
+			&entry.Slug,
+			&entry.Published,
+			&entry.Position,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		series.Posts = append(series.Posts, entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// GetPart finds the series a post belongs to and its neighbours in it. Drafts
+// are skipped in the numbering unless drafts is true.
+func (m *SeriesModel) GetPart(post string, drafts bool) (*SeriesPart, error) {
+	query := `
+	WITH entries AS (
+		SELECT s.id AS series, s.title AS series_title, p.id, p.title, p.slug,
+			row_number() OVER (ORDER BY sp.position) AS part,
+			count(*) OVER () AS total
+		FROM series_posts sp
+		INNER JOIN series s ON s.id = sp.series
+		INNER JOIN posts p ON p.id = sp.post
+		WHERE sp.series = (SELECT series FROM series_posts WHERE post = $1)
+		AND (p.published = true OR $2::boolean OR p.id = $1)
+	)
+	SELECT series, series_title, id, title, slug, part, total
+	FROM entries
+	WHERE part BETWEEN (SELECT part FROM entries WHERE id = $1) - 1
+	AND (SELECT part FROM entries WHERE id = $1) + 1
+	ORDER BY part`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, post, drafts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var part *SeriesPart
+	links := []*SeriesLink{}
+	parts := []int{}
+
+	for rows.Next() {
+		link := &SeriesLink{}
+		current := &SeriesPart{}
+
+		err = rows.Scan(
+			&current.ID,
+			&current.Title,
+			&link.ID,
+			&link.Title,
+			&link.Slug,
+			&current.Part,
+			&current.Total,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if link.ID == post {
+			part = current
+		}
+
+		links = append(links, link)
+		parts = append(parts, current.Part)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if part == nil {
+		return nil, ErrSeriesNotFound
+	}
+
+	for i, link := range links {
+		switch parts[i] {
+		case part.Part - 1:
+			part.Prev = link
+		case part.Part + 1:
+			part.Next = link
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return part, nil
+}
+
+func (m *SeriesModel) Update(series *Series) (*Series, error) {
+	query := `
+	UPDATE series
+	SET title = $1, updated = now()
+	WHERE id = $2 AND author = $3
+	RETURNING id, created, updated, author, title`
+
+	args := []any{
+		series.Title,
+		series.ID,
+		series.Author,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&series.ID,
+		&series.Created,
+		&series.Updated,
+		&series.Author,
+		&series.Title,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrSeriesNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (m *SeriesModel) Delete(id, author string) error {
+	query := `
+	DELETE FROM series
+	WHERE id = $1 AND author = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, query, id, author)
+	if err != nil {
+		return err
+	}
+
+	if cmd.RowsAffected() != 1 {
+		return ErrSeriesNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddPost appends a post to the end of a series.
+func (m *SeriesModel) AddPost(series, post string) error {
+	query := `
+	INSERT INTO series_posts (series, post, position)
+	SELECT $1, $2, COALESCE(max(position), 0) + 1
+	FROM series_posts
+	WHERE series = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, series, post)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch {
+			case strings.Contains(pgErr.Message, `duplicate key value violates unique constraint "series_posts_post_key"`):
+				return ErrPostInSeries
+			case strings.Contains(pgErr.Message, `duplicate key value violates unique constraint "series_posts_pkey"`):
+				return ErrPostInSeries
+			}
+		}
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemovePost takes a post out of a series. The positions of the posts after
+// it are shifted down by the series_posts_close_gap trigger.
+func (m *SeriesModel) RemovePost(series, post string) error {
+	query := `
+	DELETE FROM series_posts
+	WHERE series = $1 AND post = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, query, series, post)
+	if err != nil {
+		return err
+	}
+
+	if cmd.RowsAffected() != 1 {
+		return ErrPostNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reorder sets the order of every post in a series in one transaction.
+func (m *SeriesModel) Reorder(series string, posts []string) error {
+	count := `
+	SELECT count(*)
+	FROM series_posts
+	WHERE series = $1`
+
+	query := `
+	UPDATE series_posts
+	SET position = $1
+	WHERE series = $2 AND post = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var total int
+
+	err = tx.QueryRow(ctx, count, series).Scan(&total)
+	if err != nil {
+		return err
+	}
+
+	if total != len(posts) {
+		return ErrInvalidSeriesOrder
+	}
+
+	seen := map[string]bool{}
+
+	for i, post := range posts {
+		key := strings.ToLower(post)
+		if seen[key] {
+			return ErrInvalidSeriesOrder
+		}
+		seen[key] = true
+
+		cmd, err := tx.Exec(ctx, query, i+1, series, post)
+		if err != nil {
+			return err
+		}
+
+		if cmd.RowsAffected() != 1 {
+			return ErrInvalidSeriesOrder
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeries(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	posts := &PostsModel{
+		DB: tdb,
+	}
+
+	model := &SeriesModel{
+		DB: tdb,
+	}
+
+	series, err := model.Insert(&Series{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "Learning Go",
+	})
+	require.Nil(t, err)
+
+	ids := []string{}
+
+	for _, title := range []string{"part one", "part two", "part three"} {
+		post, err := posts.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: author.ID,
+			Title:  title,
+			Body:   "body",
+		})
+		require.Nil(t, err)
+
+		_, err = posts.Publish(post.ID)
+		require.Nil(t, err)
+
+		err = model.AddPost(series.ID, post.ID)
+		require.Nil(t, err)
+
+		ids = append(ids, post.ID)
+	}
+
+	t.Run("duplicate post", func(t *testing.T) {
+		err := model.AddPost(series.ID, ids[0])
+		require.NotNil(t, err)
+
+		assert.EqualError(t, err, ErrPostInSeries.Error())
+	})
+
+	t.Run("part", func(t *testing.T) {
+		part, err := model.GetPart(ids[1], false)
+		require.Nil(t, err)
+
+		assert.Equal(t, 2, part.Part)
+		assert.Equal(t, 3, part.Total)
+		assert.Equal(t, ids[0], part.Prev.ID)
+		assert.Equal(t, ids[2], part.Next.ID)
+	})
+
+	t.Run("not in series", func(t *testing.T) {
+		part, err := model.GetPart(xid.New().String(), false)
+		require.NotNil(t, err)
+		require.Nil(t, part)
+
+		assert.EqualError(t, err, ErrSeriesNotFound.Error())
+	})
+
+	t.Run("invalid reorder", func(t *testing.T) {
+		err := model.Reorder(series.ID, []string{ids[0], ids[0], ids[1]})
+		require.NotNil(t, err)
+		assert.EqualError(t, err, ErrInvalidSeriesOrder.Error())
+
+		err = model.Reorder(series.ID, []string{ids[0]})
+		require.NotNil(t, err)
+		assert.EqualError(t, err, ErrInvalidSeriesOrder.Error())
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		err := model.Reorder(series.ID, []string{ids[2], ids[0], ids[1]})
+		require.Nil(t, err)
+
+		s, err := model.GetByID(series.ID)
+		require.Nil(t, err)
+		require.Len(t, s.Posts, 3)
+
+		assert.Equal(t, ids[2], s.Posts[0].ID)
+		assert.Equal(t, ids[0], s.Posts[1].ID)
+		assert.Equal(t, ids[1], s.Posts[2].ID)
+	})
+
+	t.Run("remove closes gap", func(t *testing.T) {
+		err := model.RemovePost(series.ID, ids[2])
+		require.Nil(t, err)
+
+		s, err := model.GetByID(series.ID)
+		require.Nil(t, err)
+		require.Len(t, s.Posts, 2)
+
+		assert.Equal(t, 1, s.Posts[0].Position)
+		assert.Equal(t, 2, s.Posts[1].Position)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := model.Delete(series.ID, xid.New().String())
+		require.NotNil(t, err)
+		assert.EqualError(t, err, ErrSeriesNotFound.Error())
+
+		err = model.Delete(series.ID, author.ID)
+		require.Nil(t, err)
+	})
+}
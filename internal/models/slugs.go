@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const maxSlugLen = 80
+
+// slugify turns a post title into a lowercase, dash separated slug.
+func slugify(title string) string {
+	var b strings.Builder
+
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+
+	runes := []rune(slug)
+	if len(runes) > maxSlugLen {
+		slug = strings.TrimSuffix(string(runes[:maxSlugLen]), "-")
+	}
+
+	if slug == "" {
+		slug = "post"
+	}
+
+	return slug
+}
+
+// uniqueSlug returns the first variant of base that is not taken by another
+// post of the same author, either as a current slug or in the slug history.
+func uniqueSlug(ctx context.Context, tx pgx.Tx, author, post, base string) (string, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1 FROM posts WHERE author = $1 AND slug = $2 AND id <> $3
+	) OR EXISTS (
+		SELECT 1 FROM post_slugs WHERE author = $1 AND slug = $2 AND post <> $3
+	)`
+
+	slug := base
+
+	for i := 2; i <= 100; i++ {
+		var taken bool
+
+		err := tx.QueryRow(ctx, query, author, slug, post).Scan(&taken)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return slug, nil
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return fmt.Sprintf("%s-%s", base, strings.ToLower(post)), nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		slug  string
+	}{
+		{
+			name:  "simple",
+			title: "Hello World",
+			slug:  "hello-world",
+		},
+		{
+			name:  "punctuation",
+			title: "  Go: the good parts!! ",
+			slug:  "go-the-good-parts",
+		},
+		{
+			name:  "unicode",
+			title: "Café au lait",
+			slug:  "café-au-lait",
+		},
+		{
+			name:  "empty",
+			title: "!!!",
+			slug:  "post",
+		},
+		{
+			name:  "long",
+			title: strings.Repeat("a", 120),
+			slug:  strings.Repeat("a", maxSlugLen),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.slug, slugify(tt.title))
+		})
+	}
+}
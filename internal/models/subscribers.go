@@ -0,0 +1,357 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/xid"
+)
+
+type Subscriber interface {
+	Insert(string, string) (*Subscribers, error)
+	GetByID(string) (*Subscribers, error)
+	Confirm(string, string) (*Subscribers, error)
+	Delete(string) error
+	GetConfirmed(string, string, int) ([]*Subscribers, error)
+	Issued(string) (bool, error)
+	Issue(string) error
+}
+
+// Subscribers are the email addresses that receive an author's posts. Only
+// confirmed subscribers are mailed.
+type Subscribers struct {
+	ID        string    `json:"id"`
+	Created   time.Time `json:"created"`
+	Author    string    `json:"author"`
+	Email     string    `json:"email"`
+	Confirmed bool      `json:"confirmed"`
+}
+
+type SubscribersModel struct {
+	DB *pgxpool.Pool
+}
+
+var (
+	ErrSubscriberNotFound = errors.New("subscriber not found")
+)
+
+// Insert adds an unconfirmed subscriber to an author's newsletter, or
+// returns the existing subscriber when the address is already on it.
+func (m *SubscribersModel) Insert(author, email string) (*Subscribers, error) {
+	query := `
+	INSERT INTO subscribers (id, author, email)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (author, email) DO UPDATE SET email = subscribers.email
+	RETURNING id, created, author, email, confirmed`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	subscriber := &Subscribers{}
+
+	err = tx.QueryRow(ctx, query, xid.New().String(), author, email).Scan(
+		&subscriber.ID,
+		&subscriber.Created,
+		&subscriber.Author,
+		&subscriber.Email,
+		&subscriber.Confirmed,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriber, nil
+}
+
+func (m *SubscribersModel) GetByID(id string) (*Subscribers, error) {
+	query := `
+	SELECT id, created, author, email, confirmed
+	FROM subscribers
+	WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	subscriber := &Subscribers{}
+
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&subscriber.ID,
+		&subscriber.Created,
+		&subscriber.Author,
+		&subscriber.Email,
+		&subscriber.Confirmed,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrSubscriberNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriber, nil
+}
+
+func (m *SubscribersModel) Confirm(author, email string) (*Subscribers, error) {
+	query := `
+	UPDATE subscribers
+	SET confirmed = true
+	WHERE author = $1 AND email = $2
+	RETURNING id, created, author, email, confirmed`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	subscriber := &Subscribers{}
+
+	err = tx.QueryRow(ctx, query, author, email).Scan(
+		&subscriber.ID,
+		&subscriber.Created,
+		&subscriber.Author,
+		&subscriber.Email,
+		&subscriber.Confirmed,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrSubscriberNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriber, nil
+}
+
+func (m *SubscribersModel) Delete(id string) error {
+	query := `
+	DELETE FROM subscribers
+	WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if cmd.RowsAffected() != 1 {
+		return ErrSubscriberNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetConfirmed returns up to limit confirmed subscribers of an author in id
+// order, starting after the subscriber id after. An empty after starts at
+// the beginning.
+func (m *SubscribersModel) GetConfirmed(author, after string, limit int) ([]*Subscribers, error) {
+	query := `
+	SELECT id, created, author, email, confirmed
+	FROM subscribers
+	WHERE author = $1 AND confirmed = true AND id > $2
+	ORDER BY id
+	LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, author, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	subscribers := []*Subscribers{}
+
+	for rows.Next() {
+		subscriber := &Subscribers{}
+
+		err = rows.Scan(
+			&subscriber.ID,
+			&subscriber.Created,
+			&subscriber.Author,
+			&subscriber.Email,
+			&subscriber.Confirmed,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		subscribers = append(subscribers, subscriber)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscribers, nil
+}
+
+// Issued reports whether a post has already been sent to subscribers.
+func (m *SubscribersModel) Issued(post string) (bool, error) {
+	query := `
+	SELECT EXISTS (SELECT 1 FROM newsletter_issues WHERE post = $1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var issued bool
+
+	err = tx.QueryRow(ctx, query, post).Scan(&issued)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return issued, nil
+}
+
+// Issue records that a post has been sent to subscribers so publishing it
+// again does not mail it twice.
+func (m *SubscribersModel) Issue(post string) error {
+	query := `
+	INSERT INTO newsletter_issues (post)
+	VALUES ($1)
+	ON CONFLICT (post) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, post)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
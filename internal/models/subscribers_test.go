@@ -0,0 +1,96 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribers(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	users := &UsersModel{DB: tdb}
+
+	author := setupAuthor(t, users)
+
+	model := &SubscribersModel{
+		DB: tdb,
+	}
+
+	subscriber, err := model.Insert(author.ID, "reader@gmail.com")
+	require.Nil(t, err)
+	assert.False(t, subscriber.Confirmed)
+
+	t.Run("insert twice", func(t *testing.T) {
+		again, err := model.Insert(author.ID, "READER@gmail.com")
+		require.Nil(t, err)
+		assert.Equal(t, subscriber.ID, again.ID)
+	})
+
+	t.Run("unconfirmed are not mailed", func(t *testing.T) {
+		subscribers, err := model.GetConfirmed(author.ID, "", 10)
+		require.Nil(t, err)
+		assert.Empty(t, subscribers)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		confirmed, err := model.Confirm(author.ID, "reader@gmail.com")
+		require.Nil(t, err)
+		assert.True(t, confirmed.Confirmed)
+
+		_, err = model.Confirm(author.ID, "nobody@gmail.com")
+		assert.ErrorIs(t, err, ErrSubscriberNotFound)
+
+		second, err := model.Insert(author.ID, "second@gmail.com")
+		require.Nil(t, err)
+
+		_, err = model.Confirm(author.ID, second.Email)
+		require.Nil(t, err)
+
+		subscribers, err := model.GetConfirmed(author.ID, "", 1)
+		require.Nil(t, err)
+		require.Len(t, subscribers, 1)
+
+		subscribers, err = model.GetConfirmed(author.ID, subscribers[0].ID, 10)
+		require.Nil(t, err)
+		assert.Len(t, subscribers, 1)
+	})
+
+	t.Run("issues", func(t *testing.T) {
+		posts := &PostsModel{DB: tdb}
+
+		post, err := posts.Insert(&Posts{
+			ID:     xid.New().String(),
+			Author: author.ID,
+			Title:  "first",
+			Body:   "body",
+		})
+		require.Nil(t, err)
+
+		issued, err := model.Issued(post.ID)
+		require.Nil(t, err)
+		assert.False(t, issued)
+
+		require.Nil(t, model.Issue(post.ID))
+		require.Nil(t, model.Issue(post.ID))
+
+		issued, err = model.Issued(post.ID)
+		require.Nil(t, err)
+		assert.True(t, issued)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := model.Delete(subscriber.ID)
+		require.Nil(t, err)
+
+		_, err = model.GetByID(subscriber.ID)
+		assert.ErrorIs(t, err, ErrSubscriberNotFound)
+
+		err = model.Delete(subscriber.ID)
+		assert.ErrorIs(t, err, ErrSubscriberNotFound)
+	})
+}
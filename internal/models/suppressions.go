@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Suppression interface {
+	Insert(string, string) error
+	Delete(string, string) error
+	Suppressed(string, []string) (map[string]bool, error)
+}
+
+// SuppressionsModel records the addresses that opted out of a category of
+// bulk email. Addresses are matched without regard to case.
+type SuppressionsModel struct {
+	DB *pgxpool.Pool
+}
+
+// Insert stops email from getting mail of category. Suppressing an address
+// twice is a no-op.
+func (m *SuppressionsModel) Insert(email, category string) error {
+	query := `
+	INSERT INTO suppressions (email, category)
+	VALUES ($1, $2)
+	ON CONFLICT (category, email) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, email, category)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete lifts a suppression, as when an address subscribes again.
+func (m *SuppressionsModel) Delete(email, category string) error {
+	query := `
+	DELETE FROM suppressions
+	WHERE email = $1 AND category = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, email, category)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Suppressed returns which of emails opted out of category. The keys of the
+// result are lower cased.
+func (m *SuppressionsModel) Suppressed(category string, emails []string) (map[string]bool, error) {
+	query := `
+	SELECT email
+	FROM suppressions
+	WHERE category = $1 AND email = ANY($2::citext[])`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, category, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	suppressed := map[string]bool{}
+
+	for rows.Next() {
+		var email string
+
+		err = rows.Scan(&email)
+		if err != nil {
+			return nil, err
+		}
+
+		suppressed[strings.ToLower(email)] = true
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return suppressed, nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppressions(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	model := &SuppressionsModel{
+		DB: tdb,
+	}
+
+	emails := []string{"Reader@gmail.com", "other@gmail.com"}
+
+	suppressed, err := model.Suppressed("login_alerts", emails)
+	require.Nil(t, err)
+	assert.Empty(t, suppressed)
+
+	require.Nil(t, model.Insert("reader@gmail.com", "login_alerts"))
+	require.Nil(t, model.Insert("READER@gmail.com", "login_alerts"))
+
+	suppressed, err = model.Suppressed("login_alerts", emails)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]bool{"reader@gmail.com": true}, suppressed)
+
+	suppressed, err = model.Suppressed("newsletter", emails)
+	require.Nil(t, err)
+	assert.Empty(t, suppressed)
+
+	require.Nil(t, model.Delete("reader@gmail.com", "login_alerts"))
+
+	suppressed, err = model.Suppressed("login_alerts", emails)
+	require.Nil(t, err)
+	assert.Empty(t, suppressed)
+}
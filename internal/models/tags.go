@@ -0,0 +1,327 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/xid"
+)
+
+type Tag interface {
+	SetForPost(string, []string) ([]*Tags, error)
+	GetAllByPost(string) ([]*Tags, error)
+	GetPosts(string, *Cursor, int) ([]*Posts, error)
+	GetPopular(int) ([]*Tags, error)
+}
+
+type Tags struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Name    string    `json:"name"`
+	Posts   int       `json:"posts,omitempty"`
+}
+
+type TagsModel struct {
+	DB *pgxpool.Pool
+}
+
+// normalizeTags trims and collapses whitespace in every name and drops empty
+// names and names that only differ by case.
+func normalizeTags(names []string) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		key := strings.ToLower(name)
+
+		if name == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		tags = append(tags, name)
+	}
+
+	return tags
+}
+
+// SetForPost replaces the tags on a post, creating any tag that does not
+// exist yet.
+func (m *TagsModel) SetForPost(post string, names []string) ([]*Tags, error) {
+	upsert := `
+	INSERT INTO tags (id, name)
+	VALUES ($1, $2)
+	ON CONFLICT (name) DO UPDATE SET name = tags.name
+	RETURNING id, created, name`
+
+	unlink := `
+	DELETE FROM post_tags
+	WHERE post = $1`
+
+	link := `
+	INSERT INTO post_tags (post, tag)
+	VALUES ($1, $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadWrite,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, unlink, post)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*Tags{}
+
+	for _, name := range normalizeTags(names) {
+		tag := &Tags{}
+
+		err = tx.QueryRow(ctx, upsert, xid.New().String(), name).Scan(
+			&tag.ID,
+			&tag.Created,
+			&tag.Name,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, link, post, tag.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (m *TagsModel) GetAllByPost(post string) ([]*Tags, error) {
+	query := `
+	SELECT t.id, t.created, t.name
+	FROM tags t
+	INNER JOIN post_tags pt ON pt.tag = t.id
+	WHERE pt.post = $1
+	ORDER BY t.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, post)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []*Tags{}
+
+	for rows.Next() {
+		tag := &Tags{}
+
+		err = rows.Scan(
+			&tag.ID,
+			&tag.Created,
+			&tag.Name,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetPosts returns a page of published posts carrying the named tag, newest
+// first. The cursor is over the publish time and post id.
+func (m *TagsModel) GetPosts(name string, cursor *Cursor, limit int) ([]*Posts, error) {
+	op, order := keyset(cursor)
+
+	query := fmt.Sprintf(`
+	SELECT p.id, p.created, p.updated, p.author, p.title, p.slug, p.body, p.html, p.published, p.published_at, p.publish_at
+	FROM posts p
+	INNER JOIN post_tags pt ON pt.post = p.id
+	INNER JOIN tags t ON t.id = pt.tag
+	WHERE t.name = $1 AND p.published = true
+		AND ($2::timestamptz IS NULL OR (p.published_at, p.id) %[1]s ($2::timestamptz, $3::citext))
+	ORDER BY p.published_at %[2]s, p.id %[2]s
+	LIMIT $4`, op, order)
+
+	publishedAt, id := cursorArgs(cursor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, name, publishedAt, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []*Posts{}
+
+	for rows.Next() {
+		post := &Posts{}
+
+		err = rows.Scan(
+			&post.ID,
+			&post.Created,
+			&post.Updated,
+			&post.Author,
+			&post.Title,
+			&post.Slug,
+			&post.Body,
+			&post.HTML,
+			&post.Published,
+			&post.PublishedAt,
+			&post.PublishAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreOrder(cursor, posts)
+
+	return posts, nil
+}
+
+// GetPopular returns the tags used by the most published posts along with
+// their post counts.
+func (m *TagsModel) GetPopular(limit int) ([]*Tags, error) {
+	query := `
+	SELECT t.id, t.created, t.name, count(*) AS posts
+	FROM tags t
+	INNER JOIN post_tags pt ON pt.tag = t.id
+	INNER JOIN posts p ON p.id = pt.post
+	WHERE p.published = true
+	GROUP BY t.id
+	ORDER BY posts DESC, t.name
+	LIMIT $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []*Tags{}
+
+	for rows.Next() {
+		tag := &Tags{}
+
+		err = rows.Scan(
+			&tag.ID,
+			&tag.Created,
+			&tag.Name,
+			&tag.Posts,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/micahasowata/blog/internal/db"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags := normalizeTags([]string{" Go ", "go", "web   dev", "", "Web Dev", "postgres"})
+
+	assert.Equal(t, []string{"Go", "web dev", "postgres"}, tags)
+}
+
+func TestPostTags(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	author := setupAuthor(t, &UsersModel{DB: tdb})
+
+	posts := &PostsModel{
+		DB: tdb,
+	}
+
+	model := &TagsModel{
+		DB: tdb,
+	}
+
+	first, err := posts.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "first",
+		Body:   "body",
+	})
+	require.Nil(t, err)
+
+	second, err := posts.Insert(&Posts{
+		ID:     xid.New().String(),
+		Author: author.ID,
+		Title:  "second",
+		Body:   "body",
+	})
+	require.Nil(t, err)
+
+	_, err = model.SetForPost(first.ID, []string{"Go", "Postgres"})
+	require.Nil(t, err)
+
+	tags, err := model.SetForPost(second.ID, []string{"GO"})
+	require.Nil(t, err)
+	require.Len(t, tags, 1)
+
+	assert.Equal(t, "Go", tags[0].Name)
+
+	t.Run("post tags", func(t *testing.T) {
+		tags, err := model.GetAllByPost(first.ID)
+		require.Nil(t, err)
+
+		assert.Len(t, tags, 2)
+	})
+
+	t.Run("drafts are hidden", func(t *testing.T) {
+		posts, err := model.GetPosts("go", nil, 10)
+		require.Nil(t, err)
+
+		assert.Empty(t, posts)
+	})
+
+	_, err = posts.Publish(first.ID)
+	require.Nil(t, err)
+
+	_, err = posts.Publish(second.ID)
+	require.Nil(t, err)
+
+	t.Run("tag posts", func(t *testing.T) {
+		tagged, err := model.GetPosts("go", nil, 10)
+		require.Nil(t, err)
+		assert.Len(t, tagged, 2)
+
+		last := tagged[0]
+
+		tagged, err = model.GetPosts("go", &Cursor{Key: *last.PublishedAt, ID: last.ID}, 10)
+		require.Nil(t, err)
+		require.Len(t, tagged, 1)
+		assert.NotEqual(t, last.ID, tagged[0].ID)
+	})
+
+	t.Run("popular", func(t *testing.T) {
+		tags, err := model.GetPopular(10)
+		require.Nil(t, err)
+		require.Len(t, tags, 2)
+
+		assert.Equal(t, "Go", tags[0].Name)
+		assert.Equal(t, 2, tags[0].Posts)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		_, err := model.SetForPost(first.ID, []string{})
+		require.Nil(t, err)
+
+		tags, err := model.GetAllByPost(first.ID)
+		require.Nil(t, err)
+
+		assert.Empty(t, tags)
+	})
+}
@@ -16,6 +16,7 @@ type User interface {
 	VerifyEmail(string) (*Users, error)
 	GetByEmail(string) (*Users, error)
 	GetByID(string) (*Users, error)
+	GetByUsername(string) (*Users, error)
 	Update(*Users) (*Users, error)
 	Delete(string) error
 }
@@ -26,6 +27,9 @@ type Users struct {
 	Updated  time.Time `json:"updated"`
 	Name     string    `json:"name"`
 	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+	Bio      string    `json:"bio"`
+	Links    []string  `json:"links"`
 	Email    string    `json:"email"`
 	Verified bool      `json:"verified"`
 }
@@ -42,14 +46,21 @@ var (
 
 func (m *UsersModel) Insert(user *Users) (*Users, error) {
 	query := `
-	INSERT INTO users (id, name, username, email)
-	VALUES ($1, $2, $3, $4)
-	RETURNING id, created, updated, name, username, email, verified`
+	INSERT INTO users (id, name, username, avatar, bio, links, email)
+	VALUES ($1, $2, $3, $4, $5, $6::text[], $7)
+	RETURNING id, created, updated, name, username, avatar, bio, links::text[], email, verified`
+
+	if user.Links == nil {
+		user.Links = []string{}
+	}
 
 	args := []any{
 		user.ID,
 		user.Name,
 		user.Username,
+		user.Avatar,
+		user.Bio,
+		user.Links,
 		user.Email,
 	}
 
@@ -74,6 +85,9 @@ func (m *UsersModel) Insert(user *Users) (*Users, error) {
 		&user.Updated,
 		&user.Name,
 		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
 		&user.Email,
 		&user.Verified,
 	)
@@ -86,10 +100,9 @@ func (m *UsersModel) Insert(user *Users) (*Users, error) {
 				return nil, ErrDuplicateUsername
 			case strings.Contains(pgErr.Message, `duplicate key value violates unique constraint "users_email_key"`):
 				return nil, ErrDuplicateEmail
-			default:
-				return nil, err
 			}
 		}
+		return nil, err
 	}
 
 	err = tx.Commit(ctx)
@@ -105,7 +118,7 @@ func (m *UsersModel) VerifyEmail(email string) (*Users, error) {
 	UPDATE users 
 	SET verified = true, updated = now()
 	WHERE email = $1
-	RETURNING id, created, updated, name, username, email, verified`
+	RETURNING id, created, updated, name, username, avatar, bio, links::text[], email, verified`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -130,6 +143,9 @@ func (m *UsersModel) VerifyEmail(email string) (*Users, error) {
 		&user.Updated,
 		&user.Name,
 		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
 		&user.Email,
 		&user.Verified,
 	)
@@ -153,7 +169,7 @@ func (m *UsersModel) VerifyEmail(email string) (*Users, error) {
 
 func (m *UsersModel) GetByEmail(email string) (*Users, error) {
 	query := `
-	SELECT id, created, updated, name, username, email, verified
+	SELECT id, created, updated, name, username, avatar, bio, links::text[], email, verified
 	FROM users
 	WHERE email = $1`
 
@@ -180,6 +196,9 @@ func (m *UsersModel) GetByEmail(email string) (*Users, error) {
 		&user.Updated,
 		&user.Name,
 		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
 		&user.Email,
 		&user.Verified,
 	)
@@ -203,7 +222,7 @@ func (m *UsersModel) GetByEmail(email string) (*Users, error) {
 
 func (m *UsersModel) GetByID(id string) (*Users, error) {
 	query := `
-	SELECT id, created, updated, name, username, email, verified
+	SELECT id, created, updated, name, username, avatar, bio, links::text[], email, verified
 	FROM users
 	WHERE id = $1`
 
@@ -230,6 +249,62 @@ func (m *UsersModel) GetByID(id string) (*Users, error) {
 		&user.Updated,
 		&user.Name,
 		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
+		&user.Email,
+		&user.Verified,
+	)
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return nil, ErrUserNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (m *UsersModel) GetByUsername(username string) (*Users, error) {
+	query := `
+	SELECT id, created, updated, name, username, avatar, bio, links::text[], email, verified
+	FROM users
+	WHERE username = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:       pgx.Serializable,
+		AccessMode:     pgx.ReadOnly,
+		DeferrableMode: pgx.NotDeferrable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	user := &Users{}
+
+	err = tx.QueryRow(ctx, query, username).Scan(
+		&user.ID,
+		&user.Created,
+		&user.Updated,
+		&user.Name,
+		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
 		&user.Email,
 		&user.Verified,
 	)
@@ -254,13 +329,20 @@ func (m *UsersModel) GetByID(id string) (*Users, error) {
 func (m *UsersModel) Update(user *Users) (*Users, error) {
 	query := `
 	UPDATE users
-	SET name = $1, username = $2, email = $3, updated = now()
-	WHERE id = $4
-	RETURNING id, created, updated, name, username, email, verified`
+	SET name = $1, username = $2, avatar = $3, bio = $4, links = $5::text[], email = $6, updated = now()
+	WHERE id = $7
+	RETURNING id, created, updated, name, username, avatar, bio, links::text[], email, verified`
+
+	if user.Links == nil {
+		user.Links = []string{}
+	}
 
 	args := []any{
 		&user.Name,
 		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
 		&user.Email,
 		&user.ID,
 	}
@@ -286,6 +368,9 @@ func (m *UsersModel) Update(user *Users) (*Users, error) {
 		&user.Updated,
 		&user.Name,
 		&user.Username,
+		&user.Avatar,
+		&user.Bio,
+		&user.Links,
 		&user.Email,
 		&user.Verified,
 	)
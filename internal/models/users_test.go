@@ -55,6 +55,7 @@ func TestInsert(t *testing.T) {
 		assert.Equal(t, user.Name, createdUser.Name)
 		assert.Equal(t, user.Username, createdUser.Username)
 		assert.Equal(t, user.Email, createdUser.Email)
+		assert.Equal(t, []string{}, createdUser.Links)
 	})
 
 	t.Run("duplicate username", func(t *testing.T) {
@@ -295,6 +296,61 @@ func TestUpdateUser(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("profile", func(t *testing.T) {
+		createdUser.Avatar = "https://example.com/adam.png"
+		createdUser.Bio = "I write about Go"
+		createdUser.Links = []string{"https://github.com/iamadam"}
+
+		_, err := model.Update(createdUser)
+		require.Nil(t, err)
+
+		u, err := model.GetByID(createdUser.ID)
+		require.Nil(t, err)
+
+		assert.Equal(t, createdUser.Avatar, u.Avatar)
+		assert.Equal(t, createdUser.Bio, u.Bio)
+		assert.Equal(t, createdUser.Links, u.Links)
+	})
+}
+
+func TestUserLinks(t *testing.T) {
+	tdb := setupDB(t)
+	defer db.Clean(tdb)
+
+	model := &UsersModel{
+		DB: tdb,
+	}
+
+	links := []string{"https://github.com/iamadam", "https://Example.com/Adam"}
+
+	createdUser, err := model.Insert(&Users{
+		ID:       xid.New().String(),
+		Name:     "Adam",
+		Username: "iamadam",
+		Email:    "adam45@gmail.com",
+		Links:    links,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, links, createdUser.Links)
+
+	u, err := model.GetByID(createdUser.ID)
+	require.Nil(t, err)
+	assert.Equal(t, links, u.Links)
+
+	u, err = model.GetByUsername(createdUser.Username)
+	require.Nil(t, err)
+	assert.Equal(t, links, u.Links)
+
+	u, err = model.GetByEmail(createdUser.Email)
+	require.Nil(t, err)
+	assert.Equal(t, links, u.Links)
+
+	u.Links = links[:1]
+
+	u, err = model.Update(u)
+	require.Nil(t, err)
+	assert.Equal(t, links[:1], u.Links)
 }
 
 func TestDelete(t *testing.T) {
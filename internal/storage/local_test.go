@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	err = store.Put(ctx, "media/abc/display.png", "image/png", strings.NewReader("png bytes"))
+	require.Nil(t, err)
+
+	object, err := store.Get(ctx, "media/abc/display.png")
+	require.Nil(t, err)
+
+	body, err := io.ReadAll(object.Body)
+	require.Nil(t, err)
+	require.Nil(t, object.Body.Close())
+
+	assert.Equal(t, "png bytes", string(body))
+	assert.Equal(t, "image/png", object.ContentType)
+	assert.Equal(t, int64(9), object.Size)
+
+	_, err = store.Get(ctx, "media/abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Get(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = store.Delete(ctx, "media/abc/display.png")
+	require.Nil(t, err)
+
+	_, err = store.Get(ctx, "media/abc/display.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = store.Delete(ctx, "media/abc/display.png")
+	assert.Nil(t, err)
+}
+
+func TestNewLocal(t *testing.T) {
+	_, err := NewLocal("")
+	assert.NotNil(t, err)
+
+	dir := t.TempDir()
+
+	store, err := NewLocal(dir + "/uploads/../uploads")
+	require.Nil(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "uploads"), store.Dir)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupS3(t *testing.T) *S3 {
+	t.Helper()
+
+	backend := s3mem.New()
+	require.Nil(t, backend.CreateBucket("media"))
+
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.Nil(t, err)
+
+	store, err := NewS3(&S3Options{
+		Endpoint:  u.Host,
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+
+	require.Nil(t, err)
+
+	return store
+}
+
+func TestS3(t *testing.T) {
+	store := setupS3(t)
+	ctx := context.Background()
+
+	err := store.Put(ctx, "media/abc/display.png", "image/png", bytes.NewReader([]byte("png bytes")))
+	require.Nil(t, err)
+
+	object, err := store.Get(ctx, "media/abc/display.png")
+	require.Nil(t, err)
+
+	body, err := io.ReadAll(object.Body)
+	require.Nil(t, err)
+	require.Nil(t, object.Body.Close())
+
+	assert.Equal(t, "png bytes", string(body))
+	assert.Equal(t, "image/png", object.ContentType)
+	assert.Equal(t, int64(9), object.Size)
+
+	_, err = store.Get(ctx, "media/abc/thumbnail.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = store.Delete(ctx, "media/abc/display.png")
+	require.Nil(t, err)
+
+	_, err = store.Get(ctx, "media/abc/display.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3Presign(t *testing.T) {
+	store := setupS3(t)
+	ctx := context.Background()
+
+	putURL, err := store.PresignPut(ctx, "uploads/abc", time.Minute)
+	require.Nil(t, err)
+
+	req, err := http.NewRequest(http.MethodPut, putURL, strings.NewReader("uploaded"))
+	require.Nil(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	getURL, err := store.PresignGet(ctx, "uploads/abc", time.Minute)
+	require.Nil(t, err)
+
+	resp, err = http.Get(getURL)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "uploaded", string(body))
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/micahasowata/blog/internal/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps uploaded files under slash separated keys.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, body io.Reader) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is a stored file. Callers must close Body.
+type Object struct {
+	Body        io.ReadSeekCloser
+	ContentType string
+	Size        int64
+	Modified    time.Time
+}
+
+// Presigner is implemented by stores that can hand out short lived URLs, so
+// clients move file bytes to and from the store without going through the
+// API.
+type Presigner interface {
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+	PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// New returns the store named by cfg.Storage. Local disk is the default.
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.Storage {
+	case "", "local":
+		return NewLocal(cfg.StorageDir)
+	case "s3":
+		return NewS3(&S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage)
+	}
+}
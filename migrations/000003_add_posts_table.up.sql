@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS posts (
+    id citext PRIMARY KEY NOT NULL,
+    created timestamptz NOT NULL DEFAULT now(),
+    updated timestamptz NOT NULL DEFAULT now(),
+    author citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title citext NOT NULL,
+    body text NOT NULL,
+    published boolean NOT NULL DEFAULT false,
+    published_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS posts_author_idx ON posts (author);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS post_slugs;
+
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_author_slug_key;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS slug;
\ No newline at end of file
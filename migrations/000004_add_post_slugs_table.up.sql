@@ -0,0 +1,15 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS slug citext;
+
+UPDATE posts SET slug = id WHERE slug IS NULL;
+
+ALTER TABLE posts ALTER COLUMN slug SET NOT NULL;
+
+ALTER TABLE posts ADD CONSTRAINT posts_author_slug_key UNIQUE (author, slug);
+
+CREATE TABLE IF NOT EXISTS post_slugs (
+    author citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    slug citext NOT NULL,
+    post citext NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (author, slug)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS html text NOT NULL DEFAULT '';
\ No newline at end of file
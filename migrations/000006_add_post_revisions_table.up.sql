@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id citext PRIMARY KEY NOT NULL,
+    created timestamptz NOT NULL DEFAULT now(),
+    post citext NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    editor citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title citext NOT NULL,
+    body text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS post_revisions_post_idx ON post_revisions (post, id);
+
+CREATE OR REPLACE FUNCTION post_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'post revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER post_revisions_immutable
+BEFORE UPDATE ON post_revisions
+FOR EACH ROW EXECUTE FUNCTION post_revisions_immutable();
+
+INSERT INTO post_revisions (id, created, post, editor, title, body)
+SELECT id, updated, id, author, title, body
+FROM posts
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at timestamptz;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_tags;
+
+DROP TABLE IF EXISTS tags;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id citext PRIMARY KEY NOT NULL,
+    created timestamptz NOT NULL DEFAULT now(),
+    name citext UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post citext NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag citext NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (post, tag)
+);
+
+CREATE INDEX IF NOT EXISTS post_tags_tag_idx ON post_tags (tag);
\ No newline at end of file
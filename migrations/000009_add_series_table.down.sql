@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS series_posts;
+
+DROP FUNCTION IF EXISTS series_posts_close_gap;
+
+DROP TABLE IF EXISTS series;
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS series (
+    id citext PRIMARY KEY NOT NULL,
+    created timestamptz NOT NULL DEFAULT now(),
+    updated timestamptz NOT NULL DEFAULT now(),
+    author citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title citext NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS series_posts (
+    series citext NOT NULL REFERENCES series(id) ON DELETE CASCADE,
+    post citext UNIQUE NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position integer NOT NULL CHECK (position > 0),
+    PRIMARY KEY (series, post),
+    CONSTRAINT series_posts_position_key UNIQUE (series, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE OR REPLACE FUNCTION series_posts_close_gap() RETURNS trigger AS $$
+BEGIN
+    UPDATE series_posts
+    SET position = position - 1
+    WHERE series = OLD.series AND position > OLD.position;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER series_posts_close_gap
+AFTER DELETE ON series_posts
+FOR EACH ROW EXECUTE FUNCTION series_posts_close_gap();
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id citext PRIMARY KEY NOT NULL,
+    created timestamptz NOT NULL DEFAULT now(),
+    updated timestamptz NOT NULL DEFAULT now(),
+    post citext NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    author citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent citext REFERENCES comments(id) ON DELETE CASCADE,
+    body text NOT NULL,
+    html text NOT NULL DEFAULT '',
+    state text NOT NULL DEFAULT 'visible' CHECK (state IN ('visible', 'pending', 'hidden', 'deleted'))
+);
+
+CREATE INDEX IF NOT EXISTS comments_post_idx ON comments (post, id);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS comments_reaction_counts_cleanup ON comments;
+
+DROP TRIGGER IF EXISTS posts_reaction_counts_cleanup ON posts;
+
+DROP FUNCTION IF EXISTS reaction_counts_cleanup;
+
+DROP TABLE IF EXISTS reaction_counts;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS reaction_counts (
+    kind text NOT NULL CHECK (kind IN ('post', 'comment')),
+    target citext NOT NULL,
+    emoji text NOT NULL,
+    count integer NOT NULL DEFAULT 0 CHECK (count >= 0),
+    updated timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (kind, target, emoji)
+);
+
+CREATE OR REPLACE FUNCTION reaction_counts_cleanup() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM reaction_counts
+    WHERE kind = TG_ARGV[0] AND target = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_reaction_counts_cleanup
+AFTER DELETE ON posts
+FOR EACH ROW EXECUTE FUNCTION reaction_counts_cleanup('post');
+
+CREATE TRIGGER comments_reaction_counts_cleanup
+AFTER DELETE ON comments
+FOR EACH ROW EXECUTE FUNCTION reaction_counts_cleanup('comment');
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS posts_author_published_idx;
+
+DROP TABLE IF EXISTS follows;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS follows (
+    follower citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower, followee),
+    CHECK (follower <> followee)
+);
+
+CREATE INDEX IF NOT EXISTS follows_followee_idx ON follows (followee, follower);
+
+CREATE INDEX IF NOT EXISTS posts_author_published_idx ON posts (author, published_at DESC, id DESC) WHERE published = true;
\ No newline at end of file
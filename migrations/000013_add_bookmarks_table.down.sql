@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS reading_progress;
+
+DROP TABLE IF EXISTS bookmarks;
+
+DROP FUNCTION IF EXISTS bookmarks_close_gap;
\ No newline at end of file
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS bookmarks (
+    reader citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post citext NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created timestamptz NOT NULL DEFAULT now(),
+    position integer NOT NULL CHECK (position > 0),
+    PRIMARY KEY (reader, post),
+    CONSTRAINT bookmarks_position_key UNIQUE (reader, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE OR REPLACE FUNCTION bookmarks_close_gap() RETURNS trigger AS $$
+BEGIN
+    UPDATE bookmarks
+    SET position = position - 1
+    WHERE reader = OLD.reader AND position > OLD.position;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER bookmarks_close_gap
+AFTER DELETE ON bookmarks
+FOR EACH ROW EXECUTE FUNCTION bookmarks_close_gap();
+
+CREATE TABLE IF NOT EXISTS reading_progress (
+    reader citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post citext NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    progress smallint NOT NULL CHECK (progress BETWEEN 0 AND 100),
+    updated timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (reader, post)
+);
+
+CREATE INDEX IF NOT EXISTS reading_progress_reader_idx ON reading_progress (reader, updated DESC);
\ No newline at end of file
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS post_tags_search_update ON post_tags;
+
+DROP FUNCTION IF EXISTS post_tags_search_update;
+
+DROP TRIGGER IF EXISTS posts_search_update ON posts;
+
+DROP FUNCTION IF EXISTS posts_search_update;
+
+DROP FUNCTION IF EXISTS posts_search_document;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS search;
\ No newline at end of file
@@ -0,0 +1,40 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search tsvector NOT NULL DEFAULT ''::tsvector;
+
+CREATE OR REPLACE FUNCTION posts_search_document(post citext, title citext, body text) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', coalesce(title::text, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce((
+            SELECT string_agg(t.name::text, ' ')
+            FROM post_tags pt
+            INNER JOIN tags t ON t.id = pt.tag
+            WHERE pt.post = posts_search_document.post
+        ), '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(body, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION posts_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := posts_search_document(NEW.id, NEW.title, NEW.body);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_search_update
+BEFORE INSERT OR UPDATE OF title, body ON posts
+FOR EACH ROW EXECUTE FUNCTION posts_search_update();
+
+CREATE OR REPLACE FUNCTION post_tags_search_update() RETURNS trigger AS $$
+BEGIN
+    UPDATE posts
+    SET search = posts_search_document(id, title, body)
+    WHERE id = COALESCE(NEW.post, OLD.post);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER post_tags_search_update
+AFTER INSERT OR DELETE ON post_tags
+FOR EACH ROW EXECUTE FUNCTION post_tags_search_update();
+
+UPDATE posts SET search = posts_search_document(id, title, body);
+
+CREATE INDEX IF NOT EXISTS posts_search_idx ON posts USING GIN (search);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS newsletter_issues;
+
+DROP TABLE IF EXISTS subscribers;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS subscribers (
+    id citext PRIMARY KEY,
+    created timestamptz NOT NULL DEFAULT now(),
+    author citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email citext NOT NULL,
+    confirmed boolean NOT NULL DEFAULT false,
+    UNIQUE (author, email)
+);
+
+CREATE INDEX IF NOT EXISTS subscribers_confirmed_idx ON subscribers (author, id) WHERE confirmed = true;
+
+CREATE TABLE IF NOT EXISTS newsletter_issues (
+    post citext PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    created timestamptz NOT NULL DEFAULT now()
+);
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS suppressions (
+    email citext NOT NULL,
+    category text NOT NULL,
+    created timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (category, email)
+);
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS comments_reactions_cleanup ON comments;
+
+DROP TRIGGER IF EXISTS posts_reactions_cleanup ON posts;
+
+DROP FUNCTION IF EXISTS reactions_cleanup;
+
+DROP TABLE IF EXISTS reactions;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS reactions (
+    kind text NOT NULL CHECK (kind IN ('post', 'comment')),
+    target citext NOT NULL,
+    emoji text NOT NULL,
+    reactor citext NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (kind, target, emoji, reactor)
+);
+
+CREATE OR REPLACE FUNCTION reactions_cleanup() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM reactions
+    WHERE kind = TG_ARGV[0] AND target = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_reactions_cleanup
+AFTER DELETE ON posts
+FOR EACH ROW EXECUTE FUNCTION reactions_cleanup('post');
+
+CREATE TRIGGER comments_reactions_cleanup
+AFTER DELETE ON comments
+FOR EACH ROW EXECUTE FUNCTION reactions_cleanup('comment');
\ No newline at end of file